@@ -0,0 +1,21 @@
+package common
+
+// Image regroupe les opérations communes aux images PBM, PGM et PPM, afin que
+// les outils génériques puissent manipuler n'importe quelle image Netpbm.
+type Image interface {
+	// Size renvoie la largeur et la hauteur de l'image.
+	Size() (int, int)
+	// Save enregistre l'image dans un fichier, après l'avoir vérifiée avec Validate.
+	Save(filename string) error
+	// Validate vérifie la cohérence des dimensions, des pixels, de la valeur maximale
+	// et du nombre magique de l'image.
+	Validate() error
+	// Invert inverse les couleurs de l'image.
+	Invert()
+	// Flip retourne l'image horizontalement.
+	Flip()
+	// Flop retourne l'image verticalement.
+	Flop()
+	// SetMagicNumber définit le nombre magique, et donc l'encodage utilisé par Save.
+	SetMagicNumber(magicNumber string)
+}
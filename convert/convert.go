@@ -0,0 +1,134 @@
+package convert
+
+import (
+	"Netpbm/pbm"
+	"Netpbm/pgm"
+	"Netpbm/ppm"
+	"math"
+)
+
+// Luma donne le poids de chaque composante dans le calcul du niveau de gris.
+// La somme des poids doit valoir 1 pour que le blanc reste blanc.
+type Luma struct {
+	R, G, B float64
+}
+
+var (
+	// Rec601 correspond aux coefficients de la recommandation UIT-R BT.601 (télévision standard).
+	Rec601 = Luma{R: 0.299, G: 0.587, B: 0.114}
+	// Rec709 correspond aux coefficients de la recommandation UIT-R BT.709 (télévision haute définition).
+	Rec709 = Luma{R: 0.2126, G: 0.7152, B: 0.0722}
+	// Average donne le même poids aux trois composantes.
+	Average = Luma{R: 1.0 / 3, G: 1.0 / 3, B: 1.0 / 3}
+)
+
+// Gray renvoie le niveau de gris d'un pixel, arrondi et borné à max.
+func (l Luma) Gray(pixel ppm.Pixel16, max uint16) uint16 {
+	gray := math.Round(l.R*float64(pixel.R) + l.G*float64(pixel.G) + l.B*float64(pixel.B))
+	return uint16(math.Max(0, math.Min(gray, float64(max))))
+}
+
+// magicNumber renvoie le nombre magique du format cible, en texte si celui de la
+// source l'est et en binaire sinon. Les conversions conservent ainsi l'encodage
+// de la source, comme ses commentaires.
+func magicNumber(source, plain, raw string) string {
+	switch source {
+	case "P1", "P2", "P3":
+		return plain
+	}
+	return raw
+}
+
+// PPMToPGM convertit une image PPM en niveaux de gris avec les poids luma.
+func PPMToPGM(src *ppm.PPM, luma Luma) *pgm.PGM {
+	width, height := src.Size()
+	max := src.MaxValue()
+	dst := pgm.NewPGM(width, height)
+	dst.SetMaxValue16(max)
+	dst.SetMagicNumber(magicNumber(src.MagicNumber(), "P2", "P5"))
+	dst.SetComments(src.Comments())
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			dst.Set16(x, y, luma.Gray(src.At16(x, y), max))
+		}
+	}
+	return dst
+}
+
+// PGMToPPM convertit une image PGM en image PPM dont les trois composantes reprennent
+// le niveau de gris.
+func PGMToPPM(src *pgm.PGM) *ppm.PPM {
+	width, height := src.Size()
+	dst := ppm.NewPPM(width, height)
+	dst.SetMaxValue16(src.MaxValue())
+	dst.SetMagicNumber(magicNumber(src.MagicNumber(), "P3", "P6"))
+	dst.SetComments(src.Comments())
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			gray := src.At16(x, y)
+			dst.Set16(x, y, ppm.Pixel16{R: gray, G: gray, B: gray})
+		}
+	}
+	return dst
+}
+
+// PBMToPGM convertit une image PBM en image PGM de valeur maximale max :
+// les pixels noirs valent 0 et les pixels blancs max.
+func PBMToPGM(src *pbm.PBM, max uint16) *pgm.PGM {
+	width, height := src.Size()
+	dst := pgm.NewPGM(width, height)
+	dst.SetMaxValue16(max)
+	dst.SetMagicNumber(magicNumber(src.MagicNumber(), "P2", "P5"))
+	dst.SetComments(src.Comments())
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if !src.At(x, y) {
+				dst.Set16(x, y, max)
+			}
+		}
+	}
+	return dst
+}
+
+// PBMToPPM convertit une image PBM en image PPM de valeur maximale max :
+// les pixels noirs valent (0, 0, 0) et les pixels blancs (max, max, max).
+func PBMToPPM(src *pbm.PBM, max uint16) *ppm.PPM {
+	width, height := src.Size()
+	dst := ppm.NewPPM(width, height)
+	dst.SetMaxValue16(max)
+	dst.SetMagicNumber(magicNumber(src.MagicNumber(), "P3", "P6"))
+	dst.SetComments(src.Comments())
+	white := ppm.Pixel16{R: max, G: max, B: max}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if !src.At(x, y) {
+				dst.Set16(x, y, white)
+			}
+		}
+	}
+	return dst
+}
+
+// PGMToPBM convertit une image PGM en image PBM : les pixels strictement inférieurs
+// à threshold deviennent noirs, les autres blancs. threshold s'exprime dans la plage
+// [0, MaxValue()] de la source ; Threshold(src) coupe cette plage en son milieu.
+func PGMToPBM(src *pgm.PGM, threshold uint16) *pbm.PBM {
+	width, height := src.Size()
+	dst := pbm.NewPBM(width, height)
+	dst.SetMagicNumber(magicNumber(src.MagicNumber(), "P1", "P4"))
+	dst.SetComments(src.Comments())
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if src.At16(x, y) < threshold {
+				dst.Set(x, y, true)
+			}
+		}
+	}
+	return dst
+}
+
+// Threshold renvoie le seuil qui sépare la plage des niveaux de gris de src en deux
+// moitiés égales, à utiliser avec PGMToPBM.
+func Threshold(src *pgm.PGM) uint16 {
+	return uint16((int(src.MaxValue()) + 1) / 2)
+}
@@ -0,0 +1,105 @@
+package convert
+
+import (
+	"Netpbm/pbm"
+	"Netpbm/pgm"
+	"Netpbm/ppm"
+	"slices"
+	"testing"
+)
+
+func TestLumaGray(t *testing.T) {
+	tests := []struct {
+		name    string
+		luma    Luma
+		r, g, b uint16
+	}{
+		{"Rec601", Rec601, 76, 150, 29},
+		{"Rec709", Rec709, 54, 182, 18},
+		{"Average", Average, 85, 85, 85},
+	}
+	for _, test := range tests {
+		for _, c := range []struct {
+			pixel ppm.Pixel16
+			want  uint16
+		}{
+			{ppm.Pixel16{R: 255}, test.r},
+			{ppm.Pixel16{G: 255}, test.g},
+			{ppm.Pixel16{B: 255}, test.b},
+			{ppm.Pixel16{R: 255, G: 255, B: 255}, 255},
+			{ppm.Pixel16{}, 0},
+		} {
+			if got := test.luma.Gray(c.pixel, 255); got != c.want {
+				t.Errorf("%s : %v donne %d, %d attendu", test.name, c.pixel, got, c.want)
+			}
+		}
+	}
+}
+
+func TestPPMToPGM(t *testing.T) {
+	for _, test := range []struct{ from, to string }{{"P3", "P2"}, {"P6", "P5"}} {
+		src := ppm.NewPPM(2, 1)
+		src.SetMagicNumber(test.from)
+		src.SetMaxValue16(1000)
+		src.SetComments([]string{"source"})
+		src.Set16(0, 0, ppm.Pixel16{R: 1000, G: 1000, B: 1000})
+		src.Set16(1, 0, ppm.Pixel16{G: 1000})
+		dst := PPMToPGM(src, Rec709)
+		if dst.MagicNumber() != test.to || dst.MaxValue() != 1000 || !slices.Equal(dst.Comments(), []string{"source"}) {
+			t.Errorf("%s : %s, maxval %d, commentaires %q ; %s, maxval 1000 attendu", test.from, dst.MagicNumber(), dst.MaxValue(), dst.Comments(), test.to)
+		}
+		if dst.At16(0, 0) != 1000 || dst.At16(1, 0) != 715 {
+			t.Errorf("%s : pixels %d et %d, 1000 et 715 attendus", test.from, dst.At16(0, 0), dst.At16(1, 0))
+		}
+	}
+}
+
+func TestPGMToPBM(t *testing.T) {
+	tests := []struct {
+		max       uint16
+		threshold uint16
+		// black et white sont les niveaux les plus proches du milieu de part et d'autre du seuil
+		black, white uint16
+	}{
+		{1, 1, 0, 1},
+		{15, 8, 7, 8},
+		{255, 128, 127, 128},
+		{65535, 32768, 32767, 32768},
+	}
+	for _, test := range tests {
+		src := pgm.NewPGM(4, 1)
+		src.SetMagicNumber("P5")
+		src.SetMaxValue16(test.max)
+		for x, value := range []uint16{0, test.black, test.white, test.max} {
+			src.Set16(x, 0, value)
+		}
+		if got := Threshold(src); got != test.threshold {
+			t.Errorf("maxval %d : seuil %d, %d attendu", test.max, got, test.threshold)
+		}
+		dst := PGMToPBM(src, Threshold(src))
+		if dst.MagicNumber() != "P4" {
+			t.Errorf("maxval %d : nombre magique %s, P4 attendu", test.max, dst.MagicNumber())
+		}
+		for x, want := range []bool{true, true, false, false} {
+			if got := dst.At(x, 0); got != want {
+				t.Errorf("maxval %d : pixel %d noir %v, %v attendu", test.max, x, got, want)
+			}
+		}
+	}
+}
+
+func TestPBMToPGM(t *testing.T) {
+	for _, test := range []struct{ from, to string }{{"P1", "P2"}, {"P4", "P5"}} {
+		src := pbm.NewPBM(2, 1)
+		src.SetMagicNumber(test.from)
+		src.Set(0, 0, true)
+		dst := PBMToPGM(src, 4095)
+		if dst.MagicNumber() != test.to || dst.MaxValue() != 4095 {
+			t.Errorf("%s : %s, maxval %d ; %s, maxval 4095 attendu", test.from, dst.MagicNumber(), dst.MaxValue(), test.to)
+		}
+		// Un pixel PBM à true est noir
+		if dst.At16(0, 0) != 0 || dst.At16(1, 0) != 4095 {
+			t.Errorf("%s : noir %d, blanc %d ; 0 et 4095 attendus", test.from, dst.At16(0, 0), dst.At16(1, 0))
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package dither
+
+import (
+	"Netpbm/pbm"
+	"Netpbm/pgm"
+	"fmt"
+)
+
+// Weight indique la part de l'erreur transmise au pixel situé DX colonnes à droite
+// et DY lignes en dessous du pixel traité.
+type Weight struct {
+	DX, DY int
+	Value  int
+}
+
+// Kernel décrit un noyau de diffusion d'erreur : chaque voisin reçoit Value/Divisor
+// de l'erreur de quantification du pixel traité.
+type Kernel struct {
+	Weights []Weight
+	Divisor int
+}
+
+var (
+	// FloydSteinberg est le noyau de Floyd et Steinberg (1976), sur deux lignes.
+	FloydSteinberg = Kernel{
+		Weights: []Weight{
+			{1, 0, 7},
+			{-1, 1, 3}, {0, 1, 5}, {1, 1, 1},
+		},
+		Divisor: 16,
+	}
+	// JarvisJudiceNinke est le noyau de Jarvis, Judice et Ninke (1976), sur trois lignes.
+	JarvisJudiceNinke = Kernel{
+		Weights: []Weight{
+			{1, 0, 7}, {2, 0, 5},
+			{-2, 1, 3}, {-1, 1, 5}, {0, 1, 7}, {1, 1, 5}, {2, 1, 3},
+			{-2, 2, 1}, {-1, 2, 3}, {0, 2, 5}, {1, 2, 3}, {2, 2, 1},
+		},
+		Divisor: 48,
+	}
+	// Stucki est le noyau de Stucki (1981), une variante plus nette de celui de Jarvis, Judice et Ninke.
+	Stucki = Kernel{
+		Weights: []Weight{
+			{1, 0, 8}, {2, 0, 4},
+			{-2, 1, 2}, {-1, 1, 4}, {0, 1, 8}, {1, 1, 4}, {2, 1, 2},
+			{-2, 2, 1}, {-1, 2, 2}, {0, 2, 4}, {1, 2, 2}, {2, 2, 1},
+		},
+		Divisor: 42,
+	}
+	// Atkinson est le noyau de Bill Atkinson, qui ne diffuse que les trois quarts de
+	// l'erreur afin de préserver le contraste.
+	Atkinson = Kernel{
+		Weights: []Weight{
+			{1, 0, 1}, {2, 0, 1},
+			{-1, 1, 1}, {0, 1, 1}, {1, 1, 1},
+			{0, 2, 1},
+		},
+		Divisor: 8,
+	}
+	// Sierpinski est le noyau de Frankie Sierra sur trois lignes, aussi appelé Sierra.
+	Sierpinski = Kernel{
+		Weights: []Weight{
+			{1, 0, 5}, {2, 0, 3},
+			{-2, 1, 2}, {-1, 1, 4}, {0, 1, 5}, {1, 1, 4}, {2, 1, 2},
+			{-1, 2, 2}, {0, 2, 3}, {1, 2, 2},
+		},
+		Divisor: 32,
+	}
+)
+
+// newPBM crée l'image PBM destination, en texte si src l'est et en binaire sinon,
+// avec les commentaires de src.
+func newPBM(src *pgm.PGM) *pbm.PBM {
+	width, height := src.Size()
+	dst := pbm.NewPBM(width, height)
+	if src.MagicNumber() == "P2" {
+		dst.SetMagicNumber("P1")
+	} else {
+		dst.SetMagicNumber("P4")
+	}
+	dst.SetComments(src.Comments())
+	return dst
+}
+
+// Diffuse convertit une image PGM en image PBM en diffusant l'erreur de quantification
+// de chaque pixel vers ses voisins selon kernel. Si serpentine est vrai, les lignes sont
+// parcourues alternativement de gauche à droite et de droite à gauche, le noyau étant
+// retourné en conséquence, ce qui atténue les motifs réguliers.
+func Diffuse(src *pgm.PGM, kernel Kernel, serpentine bool) *pbm.PBM {
+	dst := newPBM(src)
+	width, height := src.Size()
+	max := float64(src.MaxValue())
+	if width == 0 || height == 0 || max == 0 || kernel.Divisor == 0 {
+		return dst
+	}
+
+	// Les erreurs des lignes à venir sont conservées dans un tampon circulaire
+	rows := 1
+	for _, w := range kernel.Weights {
+		if w.DY+1 > rows {
+			rows = w.DY + 1
+		}
+	}
+	errs := make([][]float64, rows)
+	for i := range errs {
+		errs[i] = make([]float64, width)
+	}
+
+	pix, stride := src.Pix(), src.Stride()
+	for y := 0; y < height; y++ {
+		current := errs[y%rows]
+		x, step, last := 0, 1, width
+		if serpentine && y%2 == 1 {
+			x, step, last = width-1, -1, -1
+		}
+		for ; x != last; x += step {
+			value := float64(pix[y*stride+x])/max + current[x]
+			quantized := 0.0
+			if value >= 0.5 {
+				quantized = 1
+			} else {
+				dst.Set(x, y, true)
+			}
+			diff := value - quantized
+			for _, w := range kernel.Weights {
+				nx, ny := x+w.DX*step, y+w.DY
+				if nx < 0 || nx >= width || ny >= height {
+					continue
+				}
+				errs[ny%rows][nx] += diff * float64(w.Value) / float64(kernel.Divisor)
+			}
+		}
+		clear(current)
+	}
+	return dst
+}
+
+// Ordered convertit une image PGM en image PBM par tramage ordonné avec une matrice
+// de Bayer de size × size, size valant 2, 4, 8 ou 16. Chaque pixel est comparé au seuil
+// de la matrice correspondant à sa position, ce qui produit une trame régulière.
+func Ordered(src *pgm.PGM, size int) (*pbm.PBM, error) {
+	matrix, err := Bayer(size)
+	if err != nil {
+		return nil, err
+	}
+	dst := newPBM(src)
+	width, height := src.Size()
+	max := float64(src.MaxValue())
+	if max == 0 {
+		return dst, nil
+	}
+	levels := float64(size * size)
+	pix, stride := src.Pix(), src.Stride()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			threshold := (float64(matrix[(y%size)*size+x%size]) + 0.5) / levels
+			if float64(pix[y*stride+x])/max < threshold {
+				dst.Set(x, y, true)
+			}
+		}
+	}
+	return dst, nil
+}
+
+// Bayer renvoie la matrice de Bayer de size × size, ligne par ligne : une permutation
+// des entiers de 0 à size²-1 qui répartit les seuils aussi uniformément que possible.
+// size doit valoir 2, 4, 8 ou 16.
+func Bayer(size int) ([]int, error) {
+	if size != 2 && size != 4 && size != 8 && size != 16 {
+		return nil, fmt.Errorf("taille de matrice de Bayer non valide : %d (2, 4, 8 ou 16 attendu)", size)
+	}
+	matrix, n := []int{0}, 1
+	for n < size {
+		next := make([]int, 4*n*n)
+		for y := 0; y < n; y++ {
+			for x := 0; x < n; x++ {
+				v := 4 * matrix[y*n+x]
+				next[y*2*n+x] = v
+				next[y*2*n+x+n] = v + 2
+				next[(y+n)*2*n+x] = v + 3
+				next[(y+n)*2*n+x+n] = v + 1
+			}
+		}
+		matrix, n = next, 2*n
+	}
+	return matrix, nil
+}
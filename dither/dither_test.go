@@ -0,0 +1,142 @@
+package dither
+
+import (
+	"Netpbm/pbm"
+	"Netpbm/pgm"
+	"slices"
+	"testing"
+)
+
+// kernels associe à chaque noyau prédéfini son nom.
+var kernels = map[string]Kernel{
+	"FloydSteinberg":    FloydSteinberg,
+	"JarvisJudiceNinke": JarvisJudiceNinke,
+	"Stucki":            Stucki,
+	"Atkinson":          Atkinson,
+	"Sierpinski":        Sierpinski,
+}
+
+// flat renvoie une image PGM de 64 × 64 pixels valant tous value, avec une valeur maximale de 255.
+func flat(value uint16) *pgm.PGM {
+	src := pgm.NewPGM(64, 64)
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			src.Set16(x, y, value)
+		}
+	}
+	return src
+}
+
+// blackRatio renvoie la proportion de pixels noirs de img.
+func blackRatio(img *pbm.PBM) float64 {
+	width, height := img.Size()
+	black := 0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if img.At(x, y) {
+				black++
+			}
+		}
+	}
+	return float64(black) / float64(width*height)
+}
+
+func TestBayer(t *testing.T) {
+	for _, size := range []int{2, 4, 8, 16} {
+		matrix, err := Bayer(size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sorted := slices.Clone(matrix)
+		slices.Sort(sorted)
+		for i, v := range sorted {
+			if v != i {
+				t.Fatalf("Bayer(%d) n'est pas une permutation de 0 à %d : %v", size, size*size-1, matrix)
+			}
+		}
+	}
+	if matrix, _ := Bayer(2); !slices.Equal(matrix, []int{0, 2, 3, 1}) {
+		t.Errorf("Bayer(2) = %v, [0 2 3 1] attendu", matrix)
+	}
+	for _, size := range []int{0, 1, 3, 32} {
+		if _, err := Bayer(size); err == nil {
+			t.Errorf("Bayer(%d) accepté", size)
+		}
+	}
+}
+
+func TestKernelWeights(t *testing.T) {
+	for name, kernel := range kernels {
+		sum := 0
+		for _, w := range kernel.Weights {
+			sum += w.Value
+			if w.DY < 0 || (w.DY == 0 && w.DX <= 0) {
+				t.Errorf("%s : le poids %+v vise un pixel déjà traité", name, w)
+			}
+		}
+		// Atkinson ne diffuse que les trois quarts de l'erreur
+		want := kernel.Divisor
+		if name == "Atkinson" {
+			want = 6
+		}
+		if sum != want {
+			t.Errorf("%s : somme des poids %d, %d attendu", name, sum, want)
+		}
+	}
+}
+
+func TestMidGray(t *testing.T) {
+	src := flat(128)
+	for name, kernel := range kernels {
+		for _, serpentine := range []bool{false, true} {
+			if ratio := blackRatio(Diffuse(src, kernel, serpentine)); ratio < 0.45 || ratio > 0.55 {
+				t.Errorf("Diffuse %s, serpentin %v : %.1f %% de noir, environ 50 %% attendu", name, serpentine, 100*ratio)
+			}
+		}
+	}
+	for _, size := range []int{2, 4, 8, 16} {
+		dst, err := Ordered(src, size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 128/255 dépasse légèrement le milieu : au plus un seuil de la matrice d'écart
+		if ratio := blackRatio(dst); ratio > 0.5 || ratio < 0.5-1/float64(size*size) {
+			t.Errorf("Ordered(%d) : %.1f %% de noir, environ 50 %% attendu", size, 100*ratio)
+		}
+	}
+}
+
+func TestSolid(t *testing.T) {
+	for _, test := range []struct {
+		value uint16
+		black float64
+	}{{0, 1}, {255, 0}} {
+		src := flat(test.value)
+		for name, kernel := range kernels {
+			if ratio := blackRatio(Diffuse(src, kernel, true)); ratio != test.black {
+				t.Errorf("Diffuse %s, niveau %d : %.1f %% de noir, %.0f %% attendu", name, test.value, 100*ratio, 100*test.black)
+			}
+		}
+		dst, err := Ordered(src, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ratio := blackRatio(dst); ratio != test.black {
+			t.Errorf("Ordered, niveau %d : %.1f %% de noir, %.0f %% attendu", test.value, 100*ratio, 100*test.black)
+		}
+	}
+}
+
+func TestSerpentine(t *testing.T) {
+	src := flat(77)
+	raster, serpentine := Diffuse(src, FloydSteinberg, false), Diffuse(src, FloydSteinberg, true)
+	if slices.Equal(raster.Pix(), serpentine.Pix()) {
+		t.Error("le parcours serpentin donne le même résultat que le parcours ligne par ligne")
+	}
+	// La première ligne, parcourue dans le même sens, est identique
+	for x := 0; x < 64; x++ {
+		if raster.At(x, 0) != serpentine.At(x, 0) {
+			t.Fatalf("pixel (%d, 0) différent sur la première ligne", x)
+		}
+	}
+}
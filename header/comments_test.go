@@ -0,0 +1,120 @@
+package header_test
+
+import (
+	"Netpbm/pam"
+	"Netpbm/pbm"
+	"Netpbm/pgm"
+	"Netpbm/ppm"
+	"bytes"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// commented regroupe les méthodes des images qui conservent leurs commentaires.
+type commented interface {
+	Comments() []string
+	SetComments(comments []string)
+	Save(filename string) error
+	Encode(w io.Writer) error
+}
+
+// commentFormats décrit, pour chaque format, comment créer, décoder et relire une image.
+var commentFormats = []struct {
+	name   string
+	new    func() commented
+	decode func(io.Reader) (commented, error)
+	read   func(filename string) (commented, error)
+}{
+	{"pbm", func() commented { return pbm.NewPBM(3, 2) },
+		func(r io.Reader) (commented, error) { return pbm.Decode(r) },
+		func(filename string) (commented, error) { return pbm.ReadPBM(filename) }},
+	{"pgm", func() commented { return pgm.NewPGM(3, 2) },
+		func(r io.Reader) (commented, error) { return pgm.Decode(r) },
+		func(filename string) (commented, error) { return pgm.ReadPGM(filename) }},
+	{"ppm", func() commented { return ppm.NewPPM(3, 2) },
+		func(r io.Reader) (commented, error) { return ppm.Decode(r) },
+		func(filename string) (commented, error) { return ppm.ReadPPM(filename) }},
+	{"pam", func() commented { return pam.NewPAM(3, 2, 1, 255, "GRAYSCALE") },
+		func(r io.Reader) (commented, error) { return pam.Decode(r) },
+		func(filename string) (commented, error) { return pam.ReadPAM(filename) }},
+}
+
+func TestCommentsRoundTrip(t *testing.T) {
+	comments := []string{"créé par le test", "sur\ndeux lignes", ""}
+	// Un commentaire sur plusieurs lignes est relu comme un commentaire par ligne
+	want := []string{"créé par le test", "sur", "deux lignes", ""}
+	for _, format := range commentFormats {
+		t.Run(format.name, func(t *testing.T) {
+			img := format.new()
+			img.SetComments(comments)
+			comments[0] = "modifié après SetComments"
+			defer func() { comments[0] = "créé par le test" }()
+
+			var buf bytes.Buffer
+			if err := img.Encode(&buf); err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := format.decode(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(decoded.Comments(), want) {
+				t.Errorf("Encode puis Decode : %q, %q attendus", decoded.Comments(), want)
+			}
+
+			filename := filepath.Join(t.TempDir(), "image."+format.name)
+			if err := img.Save(filename); err != nil {
+				t.Fatal(err)
+			}
+			read, err := format.read(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(read.Comments(), want) {
+				t.Errorf("Save puis lecture : %q, %q attendus", read.Comments(), want)
+			}
+		})
+	}
+}
+
+func TestCommentsBetweenFields(t *testing.T) {
+	tests := []struct {
+		format string
+		input  string
+	}{
+		{"pbm", "P1\n# a\n3 # b\n#c\n2\n0 1 0\n1 0 1\n"},
+		{"pgm", "P2\n# a\n3 # b\n2\n#c\n255\n0 1 2\n3 4 5\n"},
+		{"ppm", "P3 # a\n3\n# b\n2 #c\n255\n" + strings.Repeat("1 2 3\n", 6)},
+		{"pam", "P7\n# a\nWIDTH 3\nHEIGHT 2\n# b\nDEPTH 1\nMAXVAL 255\n#c\nENDHDR\n\x00\x01\x02\x03\x04\x05"},
+	}
+	want := []string{"a", "b", "c"}
+	for _, test := range tests {
+		for _, format := range commentFormats {
+			if format.name != test.format {
+				continue
+			}
+			img, err := format.decode(strings.NewReader(test.input))
+			if err != nil {
+				t.Fatalf("%s : %v", test.format, err)
+			}
+			if !slices.Equal(img.Comments(), want) {
+				t.Errorf("%s : %q, %q attendus", test.format, img.Comments(), want)
+			}
+			// Les commentaires relus sont réécrits avant les dimensions
+			var buf bytes.Buffer
+			if err := img.Encode(&buf); err != nil {
+				t.Fatal(err)
+			}
+			again, err := format.decode(&buf)
+			if err != nil {
+				t.Fatalf("%s réécrit : %v", test.format, err)
+			}
+			if !slices.Equal(again.Comments(), want) {
+				t.Errorf("%s réécrit : %q, %q attendus", test.format, again.Comments(), want)
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package header
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUnsupportedFormat indique un nombre magique inconnu ou non accepté par le lecteur.
+	ErrUnsupportedFormat = errors.New("format non pris en charge")
+	// ErrTruncated indique que le fichier se termine avant la fin de l'en-tête ou des pixels.
+	ErrTruncated = errors.New("fichier tronqué")
+	// ErrInvalidValue indique un champ non numérique ou hors des bornes autorisées.
+	ErrInvalidValue = errors.New("valeur non valide")
+	// ErrLimitExceeded indique qu'une image dépasse les limites fixées par Limits.
+	ErrLimitExceeded = errors.New("limite dépassée")
+)
+
+// ParseError décrit une erreur de lecture d'une image Netpbm et sa position dans le fichier.
+// Err contient l'une des erreurs ErrUnsupportedFormat, ErrTruncated, ErrInvalidValue ou
+// ErrLimitExceeded, ou l'erreur renvoyée par le lecteur sous-jacent.
+type ParseError struct {
+	Offset   int64  // position en octets depuis le début du flux
+	Line     int    // numéro de ligne, à partir de 1
+	Field    string // champ concerné, par exemple "largeur" ou "pixels"
+	Expected string // description de la valeur attendue, éventuellement vide
+	Found    string // valeur trouvée, éventuellement vide
+	Err      error
+}
+
+func (e *ParseError) Error() string {
+	msg := fmt.Sprintf("ligne %d, octet %d", e.Line, e.Offset)
+	if e.Field != "" {
+		msg += ", " + e.Field
+	}
+	msg += " : " + e.Err.Error()
+	if e.Expected != "" {
+		msg += fmt.Sprintf(" (%s attendu, %q trouvé)", e.Expected, e.Found)
+	}
+	return msg
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
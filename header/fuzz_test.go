@@ -0,0 +1,69 @@
+package header_test
+
+import (
+	"Netpbm/header"
+	"Netpbm/pam"
+	"Netpbm/pbm"
+	"Netpbm/pfm"
+	"Netpbm/pgm"
+	"Netpbm/ppm"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// FuzzDecode vérifie qu'aucune entrée ne fait paniquer les lecteurs : chaque décodeur
+// renvoie soit une erreur, soit une image valide qui peut être réécrite.
+func FuzzDecode(f *testing.F) {
+	seeds := []string{
+		"P1\n# commentaire\n3 2\n0 1 0\n1 0 1\n",
+		"P4 9 2\n\xff\x80\x00\x00",
+		"P2 2 2 15\n0 15\n7 8\n",
+		"P5 2 1 255\n\x00\xff",
+		"P5 1 1 4095\n\x0f\xff",
+		"P3 1 1 255\n1 2 3\n",
+		"P6 1 1 65535\n\x00\x01\x00\x02\x00\x03",
+		"P7\nWIDTH 2\nHEIGHT 1\nDEPTH 2\nMAXVAL 255\nTUPLTYPE GRAYSCALE_ALPHA\nENDHDR\n\x01\x02\x03\x04",
+		"PF\n1 1\n-1.0\n\x00\x00\x80\x3f\x00\x00\x00\x00\x00\x00\x00\x40",
+		"Pf 1 1 1\n\x3f\x80\x00\x00",
+	}
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+	// Des limites réduites gardent chaque exécution rapide sans changer les chemins suivis
+	limits := header.Limits{MaxWidth: 1 << 10, MaxHeight: 1 << 10, MaxPixels: 1 << 16, MaxValue: 65535, MaxDepth: 16}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkDecode(t, "pbm", data, func(r io.Reader) (encoder, error) { return pbm.DecodeWithLimits(r, limits) })
+		checkDecode(t, "pgm", data, func(r io.Reader) (encoder, error) { return pgm.DecodeWithLimits(r, limits) })
+		checkDecode(t, "ppm", data, func(r io.Reader) (encoder, error) { return ppm.DecodeWithLimits(r, limits) })
+		checkDecode(t, "pam", data, func(r io.Reader) (encoder, error) { return pam.DecodeWithLimits(r, limits) })
+		checkDecode(t, "pfm", data, func(r io.Reader) (encoder, error) { return pfm.DecodeWithLimits(r, limits) })
+	})
+}
+
+// encoder regroupe les méthodes communes aux images de tous les formats.
+type encoder interface {
+	Validate() error
+	Encode(w io.Writer) error
+}
+
+// checkDecode décode data avec decode et vérifie le résultat : une erreur doit être
+// une *header.ParseError, une image doit être valide et pouvoir être réécrite.
+func checkDecode(t *testing.T, format string, data []byte, decode func(io.Reader) (encoder, error)) {
+	t.Helper()
+	img, err := decode(bytes.NewReader(data))
+	if err != nil {
+		var parseErr *header.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%s : erreur %T (%v), *header.ParseError attendue", format, err, err)
+		}
+		return
+	}
+	if err := img.Validate(); err != nil {
+		t.Errorf("%s : image décodée non valide : %v", format, err)
+	}
+	if err := img.Encode(io.Discard); err != nil {
+		t.Errorf("%s : Encode : %v", format, err)
+	}
+}
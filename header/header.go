@@ -0,0 +1,190 @@
+package header
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Header représente l'en-tête d'une image Netpbm.
+type Header struct {
+	MagicNumber   string
+	Width, Height int
+	// MaxValue vaut 1 pour les images PBM, qui n'ont pas de valeur maximale dans leur en-tête,
+	// et 0 pour les images PFM, dont les échantillons sont des nombres réels.
+	MaxValue int
+	// Depth est le nombre d'échantillons par pixel : 1 pour PBM, PGM et PFM en niveaux
+	// de gris (Pf), 3 pour PPM et PFM en couleur (PF), la valeur du champ DEPTH pour PAM.
+	Depth int
+	// TupleType est le type de tuple d'une image PAM, vide pour les autres formats.
+	TupleType string
+	// Scale est le facteur d'échelle d'une image PFM, négatif si les échantillons sont
+	// en petit-boutiste. Il vaut 0 pour les autres formats.
+	Scale float64
+	// Comments contient le texte des commentaires de l'en-tête, sans le # initial.
+	Comments []string
+}
+
+// Plain indique si le nombre magique correspond à un format texte (P1, P2 ou P3).
+func (h Header) Plain() bool {
+	return h.MagicNumber == "P1" || h.MagicNumber == "P2" || h.MagicNumber == "P3"
+}
+
+// hasMaxValue indique si l'en-tête du format contient une valeur maximale.
+func hasMaxValue(magicNumber string) bool {
+	return magicNumber != "P1" && magicNumber != "P4" && !isPFM(magicNumber)
+}
+
+// isPFM indique si le nombre magique est celui d'une image PFM, en couleur (PF)
+// ou en niveaux de gris (Pf).
+func isPFM(magicNumber string) bool {
+	return magicNumber == "PF" || magicNumber == "Pf"
+}
+
+// Read lit l'en-tête d'une image Netpbm dont le nombre magique fait partie de magicNumbers.
+// L'en-tête d'une image PAM (P7), fait de lignes mot-clé valeur terminées par ENDHDR,
+// est lu par readPAM ; celui d'une image PFM (PF ou Pf) se termine par un facteur
+// d'échelle réel à la place de la valeur maximale. Les commentaires (# jusqu'à la fin
+// de la ligne) sont acceptés partout dans l'en-tête et les champs peuvent être séparés
+// par n'importe quelle combinaison d'espaces.
+// Le caractère d'espacement unique qui sépare l'en-tête des pixels est consommé.
+// Les commentaires rencontrés sont conservés dans Header.Comments.
+// Les erreurs de format sont renvoyées sous forme de *ParseError.
+func Read(r *Reader, magicNumbers ...string) (Header, error) {
+	var h Header
+	h.Comments, r.pending = r.pending, nil
+	r.comments = &h.Comments
+	defer func() { r.comments = nil }()
+
+	// Lire le nombre magique
+	magicNumber, err := r.ReadToken()
+	if err != nil {
+		return h, fieldError(r, "nombre magique", err)
+	}
+	supported := false
+	for _, m := range magicNumbers {
+		supported = supported || m == magicNumber
+	}
+	if !supported {
+		parseErr := r.tokenError(magicNumber, strings.Join(magicNumbers, " ou "))
+		parseErr.Field = "nombre magique"
+		parseErr.Err = ErrUnsupportedFormat
+		return h, parseErr
+	}
+	h.MagicNumber = magicNumber
+	if magicNumber == "P7" {
+		err := readPAM(r, &h)
+		return h, err
+	}
+
+	// Lire les dimensions de l'image
+	if h.Width, err = readField(r, "largeur"); err != nil {
+		return h, err
+	}
+	if h.Height, err = readField(r, "hauteur"); err != nil {
+		return h, err
+	}
+
+	// Lire la valeur maximale, ou le facteur d'échelle d'une image PFM
+	switch {
+	case isPFM(magicNumber):
+		if h.Scale, err = readScale(r); err != nil {
+			return h, err
+		}
+	case hasMaxValue(magicNumber):
+		if h.MaxValue, err = readMaxValue(r); err != nil {
+			return h, err
+		}
+	default:
+		h.MaxValue = 1
+	}
+	h.Depth = 1
+	if magicNumber == "P3" || magicNumber == "P6" || magicNumber == "PF" {
+		h.Depth = 3
+	}
+
+	// Consommer le caractère d'espacement (ou le commentaire) qui termine l'en-tête
+	b, err := r.ReadByte()
+	switch {
+	case err == io.EOF:
+		return h, nil
+	case err != nil:
+		return h, err
+	case b == '#':
+		if err := r.skipComment(); err != nil && err != io.EOF {
+			return h, err
+		}
+	case !IsSpace(b):
+		parseErr := r.NewError("en-tête", ErrInvalidValue, "espace", string(b))
+		parseErr.Offset--
+		return h, parseErr
+	}
+	return h, nil
+}
+
+// readField lit un champ numérique de l'en-tête en précisant son nom en cas d'erreur.
+func readField(r *Reader, field string) (int, error) {
+	value, err := r.ReadInt()
+	if err != nil {
+		return 0, fieldError(r, field, err)
+	}
+	return value, nil
+}
+
+// readMaxValue lit la valeur maximale de l'en-tête et vérifie qu'elle est comprise entre 1 et 65535.
+func readMaxValue(r *Reader) (int, error) {
+	value, err := readField(r, "valeur maximale")
+	if err != nil {
+		return 0, err
+	}
+	if value < 1 || value > 65535 {
+		parseErr := r.tokenError(strconv.Itoa(value), "entier entre 1 et 65535")
+		parseErr.Field = "valeur maximale"
+		return 0, parseErr
+	}
+	return value, nil
+}
+
+// readScale lit le facteur d'échelle d'une image PFM, un nombre réel fini et non nul.
+func readScale(r *Reader) (float64, error) {
+	token, err := r.ReadToken()
+	if err != nil {
+		return 0, fieldError(r, "échelle", err)
+	}
+	scale, err := strconv.ParseFloat(token, 64)
+	if err != nil || scale == 0 || math.IsInf(scale, 0) || math.IsNaN(scale) {
+		parseErr := r.tokenError(token, "nombre réel non nul")
+		parseErr.Field = "échelle"
+		return 0, parseErr
+	}
+	return scale, nil
+}
+
+// fieldError associe une erreur de lecture au champ de l'en-tête concerné.
+func fieldError(r *Reader, field string, err error) error {
+	var parseErr *ParseError
+	switch {
+	case err == io.EOF:
+		return r.NewError(field, ErrTruncated, "", "")
+	case errors.As(err, &parseErr):
+		parseErr.Field = field
+		return parseErr
+	}
+	return err
+}
+
+// WriteComments écrit chaque commentaire sur sa propre ligne, précédé de "# ".
+// Un commentaire contenant des sauts de ligne est découpé en plusieurs lignes.
+func WriteComments(w io.Writer, comments []string) error {
+	for _, comment := range comments {
+		for _, line := range strings.Split(comment, "\n") {
+			if _, err := fmt.Fprintf(w, "# %s\n", strings.TrimSuffix(line, "\r")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package header_test
+
+import (
+	"Netpbm/header"
+	"Netpbm/pam"
+	"Netpbm/pbm"
+	"Netpbm/pfm"
+	"Netpbm/pgm"
+	"Netpbm/ppm"
+	"bufio"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// decoders associe à chaque format une fonction qui décode une image sans la renvoyer.
+var decoders = map[string]func(io.Reader) error{
+	"pbm": func(r io.Reader) error { _, err := pbm.Decode(r); return err },
+	"pgm": func(r io.Reader) error { _, err := pgm.Decode(r); return err },
+	"ppm": func(r io.Reader) error { _, err := ppm.Decode(r); return err },
+	"pam": func(r io.Reader) error { _, err := pam.Decode(r); return err },
+	"pfm": func(r io.Reader) error { _, err := pfm.Decode(r); return err },
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+		err    error
+		field  string
+		line   int
+		offset int64
+	}{
+		// Fichiers tronqués
+		{"vide", "pgm", "", header.ErrTruncated, "nombre magique", 1, 0},
+		{"sans largeur", "pgm", "P2", header.ErrTruncated, "largeur", 1, 2},
+		{"sans hauteur", "pgm", "P2 3", header.ErrTruncated, "hauteur", 1, 4},
+		{"sans valeur maximale", "pgm", "P2 3 2\n", header.ErrTruncated, "valeur maximale", 2, 7},
+		{"P1 tronqué", "pbm", "P1 2 2\n0 1\n1", header.ErrTruncated, "pixels", 3, 12},
+		{"P4 tronqué", "pbm", "P4 9 2\n\x00\x00\x00", header.ErrTruncated, "pixels", 2, 10},
+		{"P2 tronqué", "pgm", "P2 3 2 255\n1 2 3\n4", header.ErrTruncated, "pixels", 3, 18},
+		{"P5 tronqué", "pgm", "P5 2 2 255\n\x01\x02\x03", header.ErrTruncated, "pixels", 2, 14},
+		{"P3 tronqué", "ppm", "P3 1 1 255\n1 2", header.ErrTruncated, "pixels", 2, 14},
+		{"P6 16 bits tronqué", "ppm", "P6 1 1 65535\n\x00\x01\x00\x02\x00", header.ErrTruncated, "pixels", 2, 18},
+		{"PAM sans ENDHDR", "pam", "P7\nWIDTH 1\n", header.ErrTruncated, "en-tête", 3, 11},
+		{"PAM tronqué", "pam", "P7\nWIDTH 2\nHEIGHT 1\nDEPTH 1\nMAXVAL 255\nENDHDR\n\x01", header.ErrTruncated, "pixels", 7, 47},
+		{"PFM tronqué", "pfm", "PF\n1 1\n-1.0\n\x00\x00", header.ErrTruncated, "pixels", 4, 14},
+
+		// Valeurs non valides
+		{"largeur non numérique", "pgm", "P2 x 1 255", header.ErrInvalidValue, "largeur", 1, 3},
+		{"hauteur négative", "pgm", "P2\n3 -2 255", header.ErrInvalidValue, "hauteur", 2, 5},
+		{"valeur maximale nulle", "pgm", "P2 1 1 0", header.ErrInvalidValue, "valeur maximale", 1, 7},
+		{"valeur maximale trop grande", "ppm", "P3 1 1\n70000", header.ErrInvalidValue, "valeur maximale", 2, 7},
+		{"en-tête collé aux pixels", "pgm", "P5 1 1 255\x07", header.ErrInvalidValue, "valeur maximale", 1, 7},
+		{"bit non valide", "pbm", "P1 2 1\n0 2", header.ErrInvalidValue, "pixels", 2, 9},
+		{"échantillon texte trop grand", "pgm", "P2 2 1 15\n3 16", header.ErrInvalidValue, "pixels", 2, 12},
+		{"échantillon binaire trop grand", "pgm", "P5 2 1 15\n\x03\x10", header.ErrInvalidValue, "pixels", 2, 11},
+		{"échantillon 16 bits trop grand", "ppm", "P6 1 1 4095\n\x00\x01\x10\x00\x00\x02", header.ErrInvalidValue, "pixels", 2, 14},
+		{"mot-clé PAM inconnu", "pam", "P7\nFOO 1\n", header.ErrInvalidValue, "en-tête", 2, 3},
+		{"PAM sans profondeur", "pam", "P7\nWIDTH 1\nHEIGHT 1\nMAXVAL 255\nENDHDR\n", header.ErrInvalidValue, "profondeur", 6, 38},
+		{"échelle PFM nulle", "pfm", "Pf 1 1 0\n", header.ErrInvalidValue, "échelle", 1, 7},
+
+		// Limites par défaut
+		{"trop large", "pgm", "P2 100000 1 255", header.ErrLimitExceeded, "largeur", 1, 15},
+		{"trop de pixels", "pgm", "P5 65536 65536 255\n", header.ErrLimitExceeded, "dimensions", 2, 19},
+		{"trop profond", "pam", "P7\nWIDTH 1\nHEIGHT 1\nDEPTH 17\nMAXVAL 255\nENDHDR\n", header.ErrLimitExceeded, "profondeur", 7, 47},
+
+		// Formats non pris en charge
+		{"PPM lu comme PGM", "pgm", "P3 1 1 255 1 2 3", header.ErrUnsupportedFormat, "nombre magique", 1, 0},
+		{"PNG", "pbm", "\x89PNG\r\n", header.ErrUnsupportedFormat, "nombre magique", 1, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := decoders[test.format](strings.NewReader(test.input))
+			if !errors.Is(err, test.err) {
+				t.Fatalf("%q : erreur %v, %v attendue", test.input, err, test.err)
+			}
+			var parseErr *header.ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("%q : erreur %T, *header.ParseError attendue", test.input, err)
+			}
+			if parseErr.Field != test.field || parseErr.Line != test.line || parseErr.Offset != test.offset {
+				t.Errorf("%q : champ %q, ligne %d, octet %d ; %q, ligne %d, octet %d attendus",
+					test.input, parseErr.Field, parseErr.Line, parseErr.Offset, test.field, test.line, test.offset)
+			}
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	input := "# avant\nP2 # largeur\n3 2\n#\n255\n"
+	r := header.NewReader(strings.NewReader(input))
+	h, err := header.Read(r, "P2", "P5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.MagicNumber != "P2" || h.Width != 3 || h.Height != 2 || h.MaxValue != 255 || h.Depth != 1 {
+		t.Errorf("en-tête %+v", h)
+	}
+	if want := []string{"avant", "largeur", ""}; !slices.Equal(h.Comments, want) {
+		t.Errorf("commentaires %q, %q attendus", h.Comments, want)
+	}
+	// Seul le saut de ligne qui suit la valeur maximale est consommé
+	if r.Offset() != int64(len(input)) || r.Line() != 6 {
+		t.Errorf("position octet %d, ligne %d après l'en-tête", r.Offset(), r.Line())
+	}
+}
+
+func TestPeekToken(t *testing.T) {
+	r := header.NewReader(strings.NewReader("\n# note\n  P5 1 1 255\n"))
+	token, err := r.PeekToken()
+	if err != nil || token != "P5" {
+		t.Fatalf("PeekToken : %q, %v", token, err)
+	}
+	if r.Offset() != 0 {
+		t.Errorf("%d octets consommés", r.Offset())
+	}
+	h, err := header.Read(r, "P5")
+	if err != nil || !slices.Equal(h.Comments, []string{"note"}) {
+		t.Errorf("Read après PeekToken : %+v, %v", h, err)
+	}
+
+	// Des commentaires plus longs que le tampon sont consommés, mais restent disponibles pour Read
+	long := "#" + strings.Repeat("x", 10000) + "\nP2 1 1 255\n"
+	r = header.NewReader(strings.NewReader(long))
+	if token, err := r.PeekToken(); err != nil || token != "P2" {
+		t.Fatalf("PeekToken au-delà du tampon : %q, %v", token, err)
+	}
+	h, err = header.Read(r, "P2")
+	if err != nil || len(h.Comments) != 1 || len(h.Comments[0]) != 10000 {
+		t.Errorf("Read après PeekToken : %d commentaires, %v", len(h.Comments), err)
+	}
+	if _, err := header.NewReader(strings.NewReader(strings.Repeat("x", 10000))).PeekToken(); err != bufio.ErrBufferFull {
+		t.Errorf("PeekToken sur un mot plus long que le tampon : %v, bufio.ErrBufferFull attendu", err)
+	}
+	if _, err := header.NewReader(strings.NewReader(" # note")).PeekToken(); err != io.EOF {
+		t.Errorf("PeekToken sans mot : %v, io.EOF attendu", err)
+	}
+}
+
+func TestLimitsCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits header.Limits
+		input  string
+		field  string
+	}{
+		{"hauteur", header.Limits{MaxHeight: 10}, "P5 1 11 255\n", "hauteur"},
+		{"valeur maximale", header.Limits{MaxValue: 255}, "P5 1 1 256\n", "valeur maximale"},
+		{"pixels", header.Limits{MaxPixels: 99}, "P5 10 10 255\n", "dimensions"},
+		{"taille de ligne", header.Limits{MaxRowBytes: 12}, "P6 3 1 255\n", "largeur"},
+		{"taille de ligne PFM", header.Limits{MaxRowBytes: 8}, "Pf 3 1 -1\n", "largeur"},
+		{"dimensions hors mémoire", header.Limits{}, "P5 4611686018427387903 4 255\n", "dimensions"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := header.NewReader(strings.NewReader(test.input))
+			h, err := header.Read(r, "P5", "P6", "Pf")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = test.limits.Check(r, h)
+			var parseErr *header.ParseError
+			if !errors.Is(err, header.ErrLimitExceeded) || !errors.As(err, &parseErr) || parseErr.Field != test.field {
+				t.Errorf("%q : %v, limite dépassée sur %q attendue", test.input, err, test.field)
+			}
+		})
+	}
+
+	// Une ligne de 100 000 pixels reste lisible avec les limites de la lecture ligne par ligne
+	r := header.NewReader(strings.NewReader("P6 100000 1000000 65535\n"))
+	h, err := header.Read(r, "P6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := header.DefaultRowLimits.Check(r, h); err != nil {
+		t.Errorf("DefaultRowLimits : %v", err)
+	}
+}
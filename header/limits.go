@@ -0,0 +1,91 @@
+package header
+
+import (
+	"fmt"
+	"math"
+)
+
+// Limits borne les images acceptées par les lecteurs, afin qu'un en-tête hostile
+// ne puisse pas provoquer une allocation démesurée. Un champ nul désactive la limite correspondante.
+type Limits struct {
+	MaxWidth  int
+	MaxHeight int
+	// MaxPixels borne le produit largeur × hauteur.
+	MaxPixels int64
+	// MaxValue borne la valeur maximale des échantillons (sans effet sur les images PBM).
+	MaxValue int
+	// MaxDepth borne le nombre d'échantillons par pixel des images PAM.
+	MaxDepth int
+	// MaxRowBytes borne la taille en mémoire d'une ligne de pixels, comptée à raison
+	// de deux octets par échantillon, ou de quatre pour les images PFM.
+	MaxRowBytes int
+}
+
+// DefaultLimits sont les limites appliquées par les fonctions Decode des paquets pbm, pgm et ppm.
+var DefaultLimits = Limits{
+	MaxWidth:  1 << 16,
+	MaxHeight: 1 << 16,
+	MaxPixels: 1 << 28,
+	MaxValue:  65535,
+	MaxDepth:  16,
+}
+
+// DefaultRowLimits sont les limites appliquées par les fonctions NewRowReader des paquets
+// pbm, pgm et ppm. Seule la taille d'une ligne est bornée, à 64 Mio : une lecture ligne
+// par ligne ne conserve qu'une ligne en mémoire, quelles que soient les dimensions de l'image.
+var DefaultRowLimits = Limits{
+	MaxValue:    65535,
+	MaxDepth:    16,
+	MaxRowBytes: 1 << 26,
+}
+
+// Check vérifie que l'en-tête h respecte les limites. Quelles que soient les limites,
+// une image dont le nombre d'échantillons ne tient pas dans un int est refusée.
+// L'erreur renvoyée est une *ParseError enveloppant ErrLimitExceeded.
+func (l Limits) Check(r *Reader, h Header) error {
+	switch {
+	case l.MaxWidth > 0 && h.Width > l.MaxWidth:
+		return l.exceeded(r, "largeur", l.MaxWidth, int64(h.Width))
+	case l.MaxHeight > 0 && h.Height > l.MaxHeight:
+		return l.exceeded(r, "hauteur", l.MaxHeight, int64(h.Height))
+	case l.MaxValue > 0 && h.MaxValue > l.MaxValue:
+		return l.exceeded(r, "valeur maximale", l.MaxValue, int64(h.MaxValue))
+	case l.MaxDepth > 0 && h.Depth > l.MaxDepth:
+		return l.exceeded(r, "profondeur", l.MaxDepth, int64(h.Depth))
+	}
+	if l.MaxRowBytes > 0 && h.Depth > 0 {
+		samples := l.MaxRowBytes / sampleBytes(h.MagicNumber)
+		if h.Depth > samples || h.Width > samples/h.Depth {
+			return r.NewError("largeur", ErrLimitExceeded, fmt.Sprintf("lignes d'au plus %d octets", l.MaxRowBytes), fmt.Sprint(h.Width))
+		}
+	}
+
+	// Quatre octets au plus par échantillon (les flottants PFM), et au moins trois
+	// échantillons par pixel afin que l'image puisse être convertie en PPM
+	samples := max(h.Depth, 3)
+	if samples > math.MaxInt/4 {
+		return r.NewError("profondeur", ErrLimitExceeded, "image adressable en mémoire", fmt.Sprint(h.Depth))
+	}
+	perPixel := 4 * samples
+	if h.Width > math.MaxInt/perPixel || (h.Width > 0 && h.Height > math.MaxInt/perPixel/h.Width) {
+		return r.NewError("dimensions", ErrLimitExceeded, "image adressable en mémoire", fmt.Sprintf("%d × %d", h.Width, h.Height))
+	}
+	if pixels := int64(h.Width) * int64(h.Height); l.MaxPixels > 0 && pixels > l.MaxPixels {
+		return r.NewError("dimensions", ErrLimitExceeded, fmt.Sprintf("au plus %d pixels", l.MaxPixels), fmt.Sprintf("%d × %d", h.Width, h.Height))
+	}
+	return nil
+}
+
+// exceeded renvoie une ParseError signalant qu'un champ dépasse sa limite.
+func (l Limits) exceeded(r *Reader, field string, limit int, value int64) error {
+	return r.NewError(field, ErrLimitExceeded, fmt.Sprintf("au plus %d", limit), fmt.Sprint(value))
+}
+
+// sampleBytes renvoie la taille en mémoire d'un échantillon : quatre octets pour les
+// flottants PFM, deux pour les autres formats.
+func sampleBytes(magicNumber string) int {
+	if isPFM(magicNumber) {
+		return 4
+	}
+	return 2
+}
@@ -0,0 +1,90 @@
+package header
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+// readPAM lit la suite de l'en-tête d'une image PAM, après le nombre magique P7.
+// Chaque ligne contient un mot-clé suivi de sa valeur ; les lignes TUPLTYPE successives
+// sont concaténées, séparées par une espace. La ligne ENDHDR termine l'en-tête.
+func readPAM(r *Reader, h *Header) error {
+	var err error
+	for {
+		keyword, tokenErr := r.ReadToken()
+		if tokenErr != nil {
+			return fieldError(r, "en-tête", tokenErr)
+		}
+		switch keyword {
+		case "WIDTH":
+			h.Width, err = readField(r, "largeur")
+		case "HEIGHT":
+			h.Height, err = readField(r, "hauteur")
+		case "DEPTH":
+			h.Depth, err = readField(r, "profondeur")
+		case "MAXVAL":
+			h.MaxValue, err = readMaxValue(r)
+		case "TUPLTYPE":
+			var tupleType string
+			if tupleType, err = readLine(r); err != nil {
+				return err
+			}
+			// Un retour chariot au milieu de la ligne ne pourrait pas être réécrit tel quel
+			if strings.Contains(tupleType, "\r") {
+				return r.NewError("type de tuple", ErrInvalidValue, "texte sur une seule ligne", tupleType)
+			}
+			if h.TupleType != "" && tupleType != "" {
+				h.TupleType += " "
+			}
+			h.TupleType += tupleType
+		case "ENDHDR":
+			if _, err := readLine(r); err != nil {
+				return err
+			}
+			return checkPAM(r, h)
+		default:
+			parseErr := r.tokenError(keyword, "WIDTH, HEIGHT, DEPTH, MAXVAL, TUPLTYPE ou ENDHDR")
+			parseErr.Field = "en-tête"
+			return parseErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readLine lit la fin de la ligne courante, saut de ligne compris, et la renvoie sans
+// les espaces qui l'entourent. La fin du flux termine la ligne.
+func readLine(r *Reader) (string, error) {
+	var line []byte
+	for {
+		b, err := r.ReadByte()
+		if err == io.EOF || b == '\n' {
+			return strings.TrimSpace(string(line)), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		line = append(line, b)
+	}
+}
+
+// checkPAM vérifie que les champs obligatoires de l'en-tête PAM sont présents et strictement positifs.
+func checkPAM(r *Reader, h *Header) error {
+	fields := []struct {
+		name  string
+		value int
+	}{
+		{"largeur", h.Width},
+		{"hauteur", h.Height},
+		{"profondeur", h.Depth},
+		{"valeur maximale", h.MaxValue},
+	}
+	for _, field := range fields {
+		if field.value < 1 {
+			return r.NewError(field.name, ErrInvalidValue, "entier strictement positif", strconv.Itoa(field.value))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,324 @@
+package header
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Reader lit une image Netpbm en suivant la position courante (octet et ligne)
+// afin de la reporter dans les erreurs.
+type Reader struct {
+	reader *bufio.Reader
+	offset int64
+	line   int
+	last   byte
+	// comments reçoit le texte des commentaires ignorés lorsqu'il n'est pas nil.
+	comments *[]string
+	// pending conserve les commentaires consommés avant l'en-tête, que Read reprend.
+	pending []string
+}
+
+// NewReader renvoie un Reader qui lit depuis r. Si r est déjà un *Reader, il est renvoyé
+// tel quel afin que plusieurs lectures successives partagent la même position.
+func NewReader(r io.Reader) *Reader {
+	if reader, ok := r.(*Reader); ok {
+		return reader
+	}
+	return &Reader{reader: bufio.NewReader(r), line: 1}
+}
+
+// Offset renvoie le nombre d'octets lus depuis le début du flux.
+func (r *Reader) Offset() int64 {
+	return r.offset
+}
+
+// Line renvoie le numéro de la ligne courante, à partir de 1.
+func (r *Reader) Line() int {
+	return r.line
+}
+
+// NewError renvoie une ParseError située à la position courante du lecteur.
+func (r *Reader) NewError(field string, err error, expected, found string) *ParseError {
+	return &ParseError{
+		Offset:   r.offset,
+		Line:     r.line,
+		Field:    field,
+		Expected: expected,
+		Found:    found,
+		Err:      err,
+	}
+}
+
+// Peek renvoie les n octets suivants sans les consommer.
+func (r *Reader) Peek(n int) ([]byte, error) {
+	return r.reader.Peek(n)
+}
+
+// Read implémente io.Reader.
+func (r *Reader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	for _, b := range p[:n] {
+		if b == '\n' {
+			r.line++
+		}
+	}
+	if n > 0 {
+		r.offset += int64(n)
+		r.last = p[n-1]
+	}
+	return n, err
+}
+
+// ReadByte implémente io.ByteReader.
+func (r *Reader) ReadByte() (byte, error) {
+	b, err := r.reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	r.offset++
+	if b == '\n' {
+		r.line++
+	}
+	r.last = b
+	return b, nil
+}
+
+// UnreadByte annule la lecture du dernier octet.
+func (r *Reader) UnreadByte() error {
+	if err := r.reader.UnreadByte(); err != nil {
+		return err
+	}
+	r.offset--
+	if r.last == '\n' {
+		r.line--
+	}
+	return nil
+}
+
+// ReadFull remplit buf avec les octets suivants, ou renvoie une ParseError
+// enveloppant ErrTruncated si le flux se termine avant.
+func (r *Reader) ReadFull(buf []byte, field string) error {
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return r.NewError(field, ErrTruncated, "", "")
+		}
+		return err
+	}
+	return nil
+}
+
+// skipComment ignore la fin d'une ligne de commentaire, saut de ligne compris.
+// Si les commentaires sont collectés, le texte est conservé sans le premier espace.
+func (r *Reader) skipComment() error {
+	var text []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil || b == '\n' {
+			if r.comments != nil {
+				text = bytes.TrimSuffix(text, []byte("\r"))
+				text = bytes.TrimPrefix(text, []byte(" "))
+				*r.comments = append(*r.comments, string(text))
+			}
+			return err
+		}
+		if r.comments != nil {
+			text = append(text, b)
+		}
+	}
+}
+
+// SkipSpace ignore les caractères d'espacement et les commentaires, par exemple entre
+// deux images d'un même flux. Le texte des commentaires est conservé pour le prochain
+// appel à Read. io.EOF est renvoyé si le flux se termine avant le prochain caractère significatif.
+func (r *Reader) SkipSpace() error {
+	return r.skipBlank()
+}
+
+// ReadToken lit le prochain mot en ignorant les espaces et les commentaires.
+// Le caractère qui termine le mot (espace ou début de commentaire) n'est pas consommé.
+// io.EOF est renvoyé si aucun mot ne reste à lire.
+func (r *Reader) ReadToken() (string, error) {
+	var token []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF && len(token) > 0 {
+				return string(token), nil
+			}
+			return "", err
+		}
+		if (IsSpace(b) || b == '#') && len(token) > 0 {
+			return string(token), r.UnreadByte()
+		}
+		switch {
+		case b == '#':
+			if err := r.skipComment(); err != nil {
+				return "", err
+			}
+		case !IsSpace(b):
+			token = append(token, b)
+		}
+	}
+}
+
+// PeekToken renvoie le prochain mot, en ignorant les espaces et les commentaires, sans
+// le consommer. Les espaces et commentaires qui le précèdent ne sont consommés que s'ils
+// dépassent le tampon du lecteur ; le texte de ces commentaires est alors conservé pour
+// le prochain appel à Read. Un mot plus long que le tampon donne bufio.ErrBufferFull.
+// io.EOF est renvoyé si aucun mot ne reste à lire.
+func (r *Reader) PeekToken() (string, error) {
+	for n := 64; ; n *= 2 {
+		buf, err := r.reader.Peek(n)
+		if token, ok := scanToken(buf, err == io.EOF); ok {
+			return token, nil
+		}
+		switch {
+		case err == nil:
+			continue
+		case err != bufio.ErrBufferFull:
+			return "", err
+		}
+		offset := r.offset
+		if err := r.skipBlank(); err != nil {
+			return "", err
+		}
+		if r.offset == offset {
+			return "", err
+		}
+		n = 32
+	}
+}
+
+// skipBlank consomme les espaces et les commentaires jusqu'au prochain mot. En dehors
+// de Read, le texte des commentaires est mis de côté pour le prochain en-tête.
+func (r *Reader) skipBlank() error {
+	if r.comments == nil {
+		r.comments = &r.pending
+		defer func() { r.comments = nil }()
+	}
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		switch {
+		case b == '#':
+			if err := r.skipComment(); err != nil {
+				return err
+			}
+		case !IsSpace(b):
+			return r.UnreadByte()
+		}
+	}
+}
+
+// scanToken cherche le premier mot de buf en ignorant les espaces et les commentaires.
+// ok est faux si buf se termine avant la fin du mot, sauf si atEOF indique que
+// le flux s'arrête là.
+func scanToken(buf []byte, atEOF bool) (token string, ok bool) {
+	i := 0
+	for i < len(buf) {
+		switch {
+		case buf[i] == '#':
+			end := bytes.IndexByte(buf[i:], '\n')
+			if end < 0 {
+				return "", false
+			}
+			i += end + 1
+		case IsSpace(buf[i]):
+			i++
+		default:
+			start := i
+			for i < len(buf) && !IsSpace(buf[i]) && buf[i] != '#' {
+				i++
+			}
+			if i == len(buf) && !atEOF {
+				return "", false
+			}
+			return string(buf[start:i]), true
+		}
+	}
+	return "", false
+}
+
+// ReadInt lit le prochain entier décimal positif en ignorant les espaces et les commentaires.
+// io.EOF est renvoyé si aucun mot ne reste à lire ; une ParseError enveloppant
+// ErrInvalidValue est renvoyée si le mot n'est pas un nombre.
+func (r *Reader) ReadInt() (int, error) {
+	token, err := r.ReadToken()
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < len(token); i++ {
+		if token[i] < '0' || token[i] > '9' {
+			return 0, r.tokenError(token, "entier positif")
+		}
+	}
+	value, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, r.tokenError(token, "entier positif")
+	}
+	return value, nil
+}
+
+// tokenError renvoie une ParseError située au début du mot qui vient d'être lu.
+func (r *Reader) tokenError(token, expected string) *ParseError {
+	err := r.NewError("", ErrInvalidValue, expected, token)
+	err.Offset -= int64(len(token))
+	return err
+}
+
+// ReadSample lit un échantillon décimal d'une image texte (P2 ou P3) et vérifie
+// qu'il ne dépasse pas max.
+func (r *Reader) ReadSample(max int) (uint16, error) {
+	value, err := r.ReadInt()
+	var parseErr *ParseError
+	switch {
+	case err == io.EOF:
+		return 0, r.NewError("pixels", ErrTruncated, "", "")
+	case errors.As(err, &parseErr):
+		parseErr.Field = "pixels"
+		parseErr.Expected = fmt.Sprintf("entier entre 0 et %d", max)
+		return 0, parseErr
+	case err != nil:
+		return 0, err
+	case value > max:
+		token := strconv.Itoa(value)
+		parseErr = r.tokenError(token, fmt.Sprintf("entier entre 0 et %d", max))
+		parseErr.Field = "pixels"
+		return 0, parseErr
+	}
+	return uint16(value), nil
+}
+
+// ReadBit lit un pixel d'une image P1, écrit 0 ou 1, les espaces entre pixels étant facultatifs.
+func (r *Reader) ReadBit() (bool, error) {
+	for {
+		b, err := r.ReadByte()
+		switch {
+		case err == io.EOF:
+			return false, r.NewError("pixels", ErrTruncated, "", "")
+		case err != nil:
+			return false, err
+		case b == '#':
+			if err := r.skipComment(); err != nil && err != io.EOF {
+				return false, err
+			}
+		case b == '0' || b == '1':
+			return b == '1', nil
+		case !IsSpace(b):
+			parseErr := r.NewError("pixels", ErrInvalidValue, "0 ou 1", string(b))
+			parseErr.Offset--
+			return false, parseErr
+		}
+	}
+}
+
+// IsSpace indique si l'octet est un caractère d'espacement au sens du format Netpbm.
+func IsSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\v' || b == '\f'
+}
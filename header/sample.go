@@ -0,0 +1,23 @@
+package header
+
+// SampleSize renvoie le nombre d'octets utilisés par un échantillon binaire d'une image
+// de valeur maximale max : un octet jusqu'à 255, deux au-delà.
+func SampleSize(max int) int {
+	if max < 256 {
+		return 1
+	}
+	return 2
+}
+
+// ScaleSample convertit un échantillon de la plage [0, from] vers la plage [0, to],
+// en arrondissant au plus proche. Un échantillon supérieur à from est ramené à to ;
+// si from est nul, le résultat vaut 0.
+func ScaleSample(value uint16, from, to int) uint16 {
+	if from <= 0 {
+		return 0
+	}
+	if int(value) > from {
+		return uint16(to)
+	}
+	return uint16((uint64(value)*uint64(to) + uint64(from)/2) / uint64(from))
+}
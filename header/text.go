@@ -0,0 +1,95 @@
+package header
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteOptions règle la mise en forme des pixels dans les formats texte (P1, P2 et P3).
+type WriteOptions struct {
+	// LineWidth borne la longueur des lignes, saut de ligne non compris. Une valeur qui
+	// dépasse à elle seule cette longueur est écrite sur sa propre ligne. Zéro désactive
+	// le retour à la ligne automatique.
+	LineWidth int
+	// Separator est écrit entre deux valeurs d'une même ligne et ne doit contenir que
+	// des caractères d'espacement. Vide, il accole les pixels des images PBM ; les
+	// autres formats utilisent alors une espace.
+	Separator string
+	// TrailingSpace ajoute le séparateur après la dernière valeur de chaque ligne.
+	TrailingSpace bool
+}
+
+// DefaultWriteOptions produisent des lignes d'au plus 70 caractères, comme le recommande
+// la spécification Netpbm. Ce sont les options utilisées par Save et Encode.
+var DefaultWriteOptions = WriteOptions{
+	LineWidth: 70,
+	Separator: " ",
+}
+
+// Check vérifie que la largeur de ligne est positive et que le séparateur
+// ne contient que des caractères d'espacement.
+func (o WriteOptions) Check() error {
+	if o.LineWidth < 0 {
+		return fmt.Errorf("largeur de ligne non valide : %d", o.LineWidth)
+	}
+	for i := 0; i < len(o.Separator); i++ {
+		if !IsSpace(o.Separator[i]) {
+			return fmt.Errorf("séparateur non valide : %q", o.Separator)
+		}
+	}
+	return nil
+}
+
+// TextWriter écrit des valeurs décimales en respectant des WriteOptions.
+// Il tamponne les écritures comme le bufio.Writer qu'il embarque : les erreurs
+// d'écriture sont conservées et renvoyées par Flush.
+type TextWriter struct {
+	*bufio.Writer
+	options WriteOptions
+	column  int
+}
+
+// NewTextWriter renvoie un TextWriter qui écrit dans w selon options.
+func NewTextWriter(w io.Writer, options WriteOptions) *TextWriter {
+	return &TextWriter{Writer: bufio.NewWriter(w), options: options}
+}
+
+// WriteValue écrit une valeur, précédée d'un retour à la ligne si elle ne tient pas
+// sur la ligne en cours.
+func (tw *TextWriter) WriteValue(value string) {
+	sep := tw.options.Separator
+	width := len(value)
+	if tw.options.TrailingSpace {
+		width += len(sep)
+	} else if tw.column > 0 {
+		width += len(sep)
+	}
+	if tw.options.LineWidth > 0 && tw.column > 0 && tw.column+width > tw.options.LineWidth {
+		tw.EndLine()
+	}
+	if tw.column > 0 && !tw.options.TrailingSpace {
+		tw.write(sep)
+	}
+	tw.write(value)
+	if tw.options.TrailingSpace {
+		tw.write(sep)
+	}
+}
+
+// EndLine termine la ligne en cours.
+func (tw *TextWriter) EndLine() {
+	tw.WriteByte('\n')
+	tw.column = 0
+}
+
+// write écrit s et met à jour la colonne courante.
+func (tw *TextWriter) write(s string) {
+	tw.WriteString(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		tw.column = len(s) - i - 1
+	} else {
+		tw.column += len(s)
+	}
+}
@@ -0,0 +1,112 @@
+package header_test
+
+import (
+	"Netpbm/header"
+	"Netpbm/pbm"
+	"Netpbm/pgm"
+	"Netpbm/ppm"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTextWriter(t *testing.T) {
+	tests := []struct {
+		name    string
+		options header.WriteOptions
+		values  []string
+		want    string
+	}{
+		{"sans retour à la ligne", header.WriteOptions{Separator: " "}, []string{"1", "22", "333"}, "1 22 333\n"},
+		{"retour à la ligne", header.WriteOptions{LineWidth: 6, Separator: " "}, []string{"1", "22", "333", "4"}, "1 22\n333 4\n"},
+		{"largeur exacte", header.WriteOptions{LineWidth: 4, Separator: " "}, []string{"1", "22", "3"}, "1 22\n3\n"},
+		{"séparateur tabulation", header.WriteOptions{LineWidth: 5, Separator: "\t"}, []string{"1", "2", "3"}, "1\t2\t3\n"},
+		{"séparateur vide", header.WriteOptions{LineWidth: 3}, []string{"0", "1", "1", "0"}, "011\n0\n"},
+		{"espace final", header.WriteOptions{LineWidth: 4, Separator: " ", TrailingSpace: true}, []string{"1", "2", "3"}, "1 2 \n3 \n"},
+		// Une valeur plus longue que la ligne est écrite seule sur sa ligne
+		{"valeur trop longue", header.WriteOptions{LineWidth: 3, Separator: " "}, []string{"1", "65535", "2"}, "1\n65535\n2\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tw := header.NewTextWriter(&buf, test.options)
+			for _, value := range test.values {
+				tw.WriteValue(value)
+			}
+			tw.EndLine()
+			if err := tw.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != test.want {
+				t.Errorf("%q, %q attendu", buf.String(), test.want)
+			}
+		})
+	}
+}
+
+// textEncoder est implémenté par les images PBM, PGM et PPM.
+type textEncoder interface {
+	EncodeWithOptions(w io.Writer, options header.WriteOptions) error
+}
+
+func TestEncodeWithOptions(t *testing.T) {
+	bitmap := pbm.NewPBM(150, 2)
+	gray := pgm.NewPGM(40, 2)
+	gray.SetMaxValue16(65535)
+	color := ppm.NewPPM(30, 2)
+	color.SetMaxValue16(1000)
+	for x := 0; x < 150; x++ {
+		bitmap.Set(x, 1, x%3 == 0)
+	}
+	for x := 0; x < 40; x++ {
+		gray.Set16(x, 0, uint16(x*1500))
+	}
+	for x := 0; x < 30; x++ {
+		color.Set16(x, 1, ppm.Pixel16{R: uint16(x), G: 1000, B: uint16(x * 30)})
+	}
+	images := []struct {
+		format      string
+		img         textEncoder
+		headerLines int
+	}{
+		{"pbm", bitmap, 2},
+		{"pgm", gray, 3},
+		{"ppm", color, 3},
+	}
+
+	tests := []struct {
+		name    string
+		options header.WriteOptions
+		width   int
+	}{
+		{"options par défaut", header.DefaultWriteOptions, 70},
+		{"lignes courtes", header.WriteOptions{LineWidth: 12, Separator: " "}, 12},
+		{"tabulations et espace final", header.WriteOptions{LineWidth: 20, Separator: "\t", TrailingSpace: true}, 20},
+		// Aucune valeur ne tient sur la ligne : une valeur par ligne
+		{"valeurs plus longues que la ligne", header.WriteOptions{LineWidth: 2, Separator: " "}, 5},
+	}
+	for _, test := range tests {
+		for _, image := range images {
+			t.Run(test.name+" "+image.format, func(t *testing.T) {
+				var buf bytes.Buffer
+				if err := image.img.EncodeWithOptions(&buf, test.options); err != nil {
+					t.Fatal(err)
+				}
+				lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+				for i, line := range lines {
+					if len(line) > test.width {
+						t.Errorf("ligne %d de %d caractères, au plus %d attendus : %q", i+1, len(line), test.width, line)
+					}
+					trailing := strings.TrimRight(line, " \t") != line
+					if i >= image.headerLines && trailing != test.options.TrailingSpace {
+						t.Errorf("ligne %d : espace final %v, %v attendu : %q", i+1, trailing, test.options.TrailingSpace, line)
+					}
+				}
+				if err := decoders[image.format](&buf); err != nil {
+					t.Errorf("relecture : %v", err)
+				}
+			})
+		}
+	}
+}
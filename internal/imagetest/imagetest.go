@@ -0,0 +1,52 @@
+// Package imagetest regroupe les fonctions d'aide communes aux tests des paquets d'images.
+package imagetest
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// Image regroupe les méthodes communes aux images PGM et PPM, dont les pixels sont de type P.
+type Image[P comparable] interface {
+	Size() (int, int)
+	MaxValue() uint16
+	MagicNumber() string
+	At16(x, y int) P
+	Encode(w io.Writer) error
+}
+
+// RoundTrip encode img puis le relit avec decode, et vérifie que l'image relue est
+// identique à img.
+func RoundTrip[P comparable, I Image[P]](t testing.TB, img I, decode func(io.Reader) (I, error)) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := img.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	width, _ := img.Size()
+	decoded, err := decode(&buf)
+	if err != nil {
+		t.Fatalf("%s, maxval %d, largeur %d : %v", img.MagicNumber(), img.MaxValue(), width, err)
+	}
+	Equal(t, decoded, img)
+}
+
+// Equal vérifie que got a les mêmes dimensions, le même nombre magique, la même valeur
+// maximale et les mêmes pixels que want.
+func Equal[P comparable, I Image[P]](t testing.TB, got, want I) {
+	t.Helper()
+	gotWidth, gotHeight := got.Size()
+	wantWidth, wantHeight := want.Size()
+	if gotWidth != wantWidth || gotHeight != wantHeight || got.MaxValue() != want.MaxValue() || got.MagicNumber() != want.MagicNumber() {
+		t.Fatalf("%s %d × %d, maxval %d ; %s %d × %d, maxval %d attendu", got.MagicNumber(), gotWidth, gotHeight, got.MaxValue(),
+			want.MagicNumber(), wantWidth, wantHeight, want.MaxValue())
+	}
+	for y := 0; y < wantHeight; y++ {
+		for x := 0; x < wantWidth; x++ {
+			if got.At16(x, y) != want.At16(x, y) {
+				t.Fatalf("pixel (%d, %d) vaut %v, %v attendu", x, y, got.At16(x, y), want.At16(x, y))
+			}
+		}
+	}
+}
@@ -168,7 +168,7 @@ func main() {
 
 	// Créer une nouvelle image PPM de 100x100 et dessiner une ligne rouge au centre
 	imagePPM = ppm.NewPPM(100, 100)
-	imagePPM.DrawLine(ppm.Point{0, 50}, ppm.Point{99, 50}, ppm.Pixel{255, 0, 0})
+	imagePPM.DrawLine(ppm.Point{X: 0, Y: 50}, ppm.Point{X: 99, Y: 50}, ppm.Pixel{R: 255, G: 0, B: 0})
 	err = imagePPM.Save("Line.ppm")
 	if err != nil {
 		log.Fatal("Erreur Line :", err)
@@ -176,7 +176,7 @@ func main() {
 
 	// Créer une nouvelle image PPM de 100x100 et dessiner un rectangle blanc
 	imagePPM = ppm.NewPPM(100, 100)
-	imagePPM.DrawRectangle(ppm.Point{20, 30}, 60, 40, ppm.Pixel{255, 255, 255})
+	imagePPM.DrawRectangle(ppm.Point{X: 20, Y: 30}, 60, 40, ppm.Pixel{R: 255, G: 255, B: 255})
 	err = imagePPM.Save("Rectangle.ppm")
 	if err != nil {
 		log.Fatal("Erreur Rectangle :", err)
@@ -184,7 +184,7 @@ func main() {
 
 	// Créer une nouvelle image PPM de 100x100 et dessiner un rectangle blanc rempli
 	imagePPM = ppm.NewPPM(100, 100)
-	imagePPM.DrawFilledRectangle(ppm.Point{20, 30}, 60, 40, ppm.Pixel{255, 255, 255})
+	imagePPM.DrawFilledRectangle(ppm.Point{X: 20, Y: 30}, 60, 40, ppm.Pixel{R: 255, G: 255, B: 255})
 	err = imagePPM.Save("FilledRectangle.ppm")
 	if err != nil {
 		log.Fatal("Erreur FilledRectangle :", err)
@@ -192,7 +192,7 @@ func main() {
 
 	// Créer une nouvelle image PPM de 100x100 et dessiner un cercle blanc
 	imagePPM = ppm.NewPPM(100, 100)
-	imagePPM.DrawCircle(ppm.Point{50, 50}, 20, ppm.Pixel{R: 255, G: 255, B: 255})
+	imagePPM.DrawCircle(ppm.Point{X: 50, Y: 50}, 20, ppm.Pixel{R: 255, G: 255, B: 255})
 	err = imagePPM.Save("circle.ppm")
 	if err != nil {
 		log.Fatal("Erreur circle :", err)
@@ -200,7 +200,7 @@ func main() {
 
 	// Créer une nouvelle image PPM de 100x100 et dessiner un cercle blanc rempli
 	imagePPM = ppm.NewPPM(100, 100)
-	imagePPM.DrawFilledCircle(ppm.Point{50, 50}, 20, ppm.Pixel{R: 255, G: 255, B: 255})
+	imagePPM.DrawFilledCircle(ppm.Point{X: 50, Y: 50}, 20, ppm.Pixel{R: 255, G: 255, B: 255})
 	err = imagePPM.Save("FilledCircle.ppm")
 	if err != nil {
 		log.Fatal("Erreur FilledCircle :", err)
@@ -208,7 +208,7 @@ func main() {
 
 	// Créer une nouvelle image PPM de 100x100 et dessiner un triangle blanc
 	imagePPM = ppm.NewPPM(100, 100)
-	imagePPM.DrawTriangle(ppm.Point{20, 80}, ppm.Point{80, 80}, ppm.Point{50, 20}, ppm.Pixel{R: 255, G: 255, B: 255})
+	imagePPM.DrawTriangle(ppm.Point{X: 20, Y: 80}, ppm.Point{X: 80, Y: 80}, ppm.Point{X: 50, Y: 20}, ppm.Pixel{R: 255, G: 255, B: 255})
 	err = imagePPM.Save("Triangle.ppm")
 	if err != nil {
 		log.Fatal("Erreur Triangle :", err)
@@ -216,7 +216,7 @@ func main() {
 
 	// Créer une nouvelle image PPM de 100x100 et dessiner un triangle blanc rempli
 	imagePPM = ppm.NewPPM(100, 100)
-	imagePPM.DrawFilledTriangle(ppm.Point{20, 80}, ppm.Point{80, 80}, ppm.Point{50, 20}, ppm.Pixel{R: 255, G: 255, B: 255})
+	imagePPM.DrawFilledTriangle(ppm.Point{X: 20, Y: 80}, ppm.Point{X: 80, Y: 80}, ppm.Point{X: 50, Y: 20}, ppm.Pixel{R: 255, G: 255, B: 255})
 	err = imagePPM.Save("FilledTriangle.ppm")
 	if err != nil {
 		log.Fatal("Erreur FilledTriangle :", err)
@@ -224,7 +224,7 @@ func main() {
 
 	// Créer une nouvelle image PPM de 100x100 et dessiner un polygone blanc
 	imagePPM = ppm.NewPPM(100, 100)
-	polygonPoints := []ppm.Point{{20, 80}, {80, 80}, {50, 20}, {30, 40}}
+	polygonPoints := []ppm.Point{{X: 20, Y: 80}, {X: 80, Y: 80}, {X: 50, Y: 20}, {X: 30, Y: 40}}
 	imagePPM.DrawPolygon(polygonPoints, ppm.Pixel{R: 255, G: 255, B: 255})
 	err = imagePPM.Save("Polygon.ppm")
 	if err != nil {
@@ -247,7 +247,7 @@ func main() {
 
 	// Créez une image PPM et Dessine le triangle de Sierpinski
 	imagePPM = ppm.NewPPM(100, 100)
-	imagePPM.DrawSierpinskiTriangle(4, ppm.Point{25, 75}, 50, ppm.Pixel{255, 0, 0})
+	imagePPM.DrawSierpinskiTriangle(4, ppm.Point{X: 25, Y: 75}, 50, ppm.Pixel{R: 255, G: 0, B: 0})
 	imagePPM.Save("SierpinskiTriangle.ppm")
 
 }
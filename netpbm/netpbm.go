@@ -0,0 +1,65 @@
+package netpbm
+
+import (
+	"Netpbm/common"
+	"Netpbm/header"
+	"Netpbm/pam"
+	"Netpbm/pbm"
+	"Netpbm/pgm"
+	"Netpbm/ppm"
+	"bufio"
+	"io"
+	"os"
+)
+
+// Image regroupe les opérations communes aux images PBM, PGM, PPM et PAM.
+type Image = common.Image
+
+// Open lit une image Netpbm depuis un fichier, quelle que soit son extension,
+// et renvoie une valeur de type *pbm.PBM, *pgm.PGM, *ppm.PPM ou *pam.PAM selon son nombre magique.
+func Open(filename string) (Image, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Decode(file)
+}
+
+// Decode lit une image Netpbm depuis r en détectant son format d'après le nombre magique.
+// Les espaces et les commentaires qui précèdent le nombre magique sont acceptés, comme
+// par les fonctions Decode de chaque format.
+// Une *header.ParseError enveloppant header.ErrUnsupportedFormat est renvoyée pour les
+// formats non pris en charge.
+func Decode(r io.Reader) (Image, error) {
+	reader := header.NewReader(r)
+
+	magic, err := reader.PeekToken()
+	switch {
+	case err == io.EOF:
+		return nil, reader.NewError("nombre magique", header.ErrTruncated, "", "")
+	case err == bufio.ErrBufferFull:
+		// Un mot plus long que le tampon de lecture ne peut pas être un nombre magique
+		return nil, reader.NewError("nombre magique", header.ErrUnsupportedFormat, "P1 à P7", "")
+	case err != nil:
+		return nil, reader.NewError("nombre magique", err, "", "")
+	}
+	var img Image
+	switch magic {
+	case "P1", "P4":
+		img, err = pbm.Decode(reader)
+	case "P2", "P5":
+		img, err = pgm.Decode(reader)
+	case "P3", "P6":
+		img, err = ppm.Decode(reader)
+	case "P7":
+		img, err = pam.Decode(reader)
+	default:
+		return nil, reader.NewError("nombre magique", header.ErrUnsupportedFormat, "P1 à P7", magic)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
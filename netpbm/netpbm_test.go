@@ -0,0 +1,133 @@
+package netpbm
+
+import (
+	"Netpbm/header"
+	"Netpbm/pam"
+	"Netpbm/pbm"
+	"Netpbm/pgm"
+	"Netpbm/ppm"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		check func(t *testing.T, img Image)
+	}{
+		{"P1", "P1 2 1 0 1", isPBM},
+		{"P4", "P4 2 1\n\x40", isPBM},
+		{"P2", "P2 1 1 255 7", isPGM},
+		{"P5", "P5 1 1 255\n\x07", isPGM},
+		{"P3", "P3 1 1 255 1 2 3", isPPM},
+		{"P6", "P6 1 1 255\n\x01\x02\x03", isPPM},
+		{"P7", "P7\nWIDTH 1\nHEIGHT 1\nDEPTH 1\nMAXVAL 255\nTUPLTYPE GRAYSCALE\nENDHDR\n\x07", isPAM},
+		{"saut de ligne initial", "\nP2 1 1 255 7", isPGM},
+		{"espaces initiaux", " \t\r\n P5 1 1 255\n\x07", isPGM},
+		{"commentaire initial", "# scanner note\nP2 1 1 255 7", func(t *testing.T, img Image) {
+			isPGM(t, img)
+			if comments := img.(*pgm.PGM).Comments(); !slices.Equal(comments, []string{"scanner note"}) {
+				t.Errorf("commentaires %q, [\"scanner note\"] attendu", comments)
+			}
+		}},
+		{"commentaires et lignes vides", "\n# a\n\n#b\r\n  P3 1 1 255 1 2 3", isPPM},
+		{"commentaire collé au nombre magique", "P2# note\n1 1 255 7", isPGM},
+		{"commentaires plus longs que le tampon", strings.Repeat("# "+strings.Repeat("x", 1000)+"\n", 10) + "P2 1 1 255 7", func(t *testing.T, img Image) {
+			isPGM(t, img)
+			if comments := img.(*pgm.PGM).Comments(); len(comments) != 10 || comments[9] != strings.Repeat("x", 1000) {
+				t.Errorf("%d commentaires, 10 attendus", len(comments))
+			}
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			img, err := Decode(strings.NewReader(test.input))
+			if err != nil {
+				t.Fatalf("Decode(%q) : %v", test.input, err)
+			}
+			test.check(t, img)
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   error
+	}{
+		{"vide", "", header.ErrTruncated},
+		{"espaces seuls", " \n\t", header.ErrTruncated},
+		{"commentaire seul", "# note", header.ErrTruncated},
+		{"format inconnu", "# note\nGIF89a", header.ErrUnsupportedFormat},
+		{"PFM", "PF 1 1 -1.0\n", header.ErrUnsupportedFormat},
+		{"mot plus long que le tampon", strings.Repeat("P", 10000), header.ErrUnsupportedFormat},
+		{"commentaire long sans image", "#" + strings.Repeat("x", 10000), header.ErrTruncated},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Decode(strings.NewReader(test.input))
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Decode(%q) : %v, %v attendu", test.input, err, test.err)
+			}
+			var parseErr *header.ParseError
+			if !errors.As(err, &parseErr) || parseErr.Field != "nombre magique" {
+				t.Errorf("Decode(%q) : %#v, ParseError sur le nombre magique attendue", test.input, err)
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	// L'extension ne correspond pas au contenu
+	filename := filepath.Join(t.TempDir(), "scan.pbm")
+	if err := os.WriteFile(filename, []byte("# scanner note\nP2 1 1 255 7"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	img, err := Open(filename)
+	if err != nil {
+		t.Fatalf("Open : %v", err)
+	}
+	isPGM(t, img)
+}
+
+func isPBM(t *testing.T, img Image) {
+	t.Helper()
+	if _, ok := img.(*pbm.PBM); !ok {
+		t.Fatalf("%T, *pbm.PBM attendu", img)
+	}
+}
+
+func isPGM(t *testing.T, img Image) {
+	t.Helper()
+	pgm, ok := img.(*pgm.PGM)
+	if !ok {
+		t.Fatalf("%T, *pgm.PGM attendu", img)
+	}
+	if got := pgm.At(0, 0); got != 7 {
+		t.Errorf("pixel %d, 7 attendu", got)
+	}
+}
+
+func isPPM(t *testing.T, img Image) {
+	t.Helper()
+	ppm, ok := img.(*ppm.PPM)
+	if !ok {
+		t.Fatalf("%T, *ppm.PPM attendu", img)
+	}
+	if got := ppm.At(0, 0); got.R != 1 || got.G != 2 || got.B != 3 {
+		t.Errorf("pixel %v, {1 2 3} attendu", got)
+	}
+}
+
+func isPAM(t *testing.T, img Image) {
+	t.Helper()
+	if _, ok := img.(*pam.PAM); !ok {
+		t.Fatalf("%T, *pam.PAM attendu", img)
+	}
+}
@@ -0,0 +1,48 @@
+package netpbm_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	_ "Netpbm/pbm"
+	_ "Netpbm/pgm"
+	_ "Netpbm/ppm"
+)
+
+func TestRegisterFormat(t *testing.T) {
+	tests := []struct {
+		input  string
+		format string
+		model  color.Model
+		want   color.Color
+	}{
+		{"P1 2 1 0 1", "pbm", color.GrayModel, color.Gray{0}},
+		{"P4 2 1\n\x40", "pbm", color.GrayModel, color.Gray{0}},
+		{"P2 2 1 255 0 7", "pgm", color.GrayModel, color.Gray{7}},
+		{"P5 2 1 65535\n\x00\x00\x12\x34", "pgm", color.Gray16Model, color.Gray16{0x1234}},
+		{"P3 2 1 255 0 0 0 1 2 3", "ppm", color.RGBAModel, color.RGBA{1, 2, 3, 255}},
+		{"P6 2 1 255\n\x00\x00\x00\x01\x02\x03", "ppm", color.RGBAModel, color.RGBA{1, 2, 3, 255}},
+	}
+	for _, test := range tests {
+		config, format, err := image.DecodeConfig(bytes.NewReader([]byte(test.input)))
+		if err != nil {
+			t.Fatalf("DecodeConfig(%q) : %v", test.input, err)
+		}
+		// Le modèle noir et blanc des images PBM est une fonction, qui ne se compare pas
+		if format != test.format || config.Width != 2 || config.Height != 1 || (test.format != "pbm" && config.ColorModel != test.model) {
+			t.Errorf("DecodeConfig(%q) : %s %d × %d, %s 2 × 1 attendu", test.input, format, config.Width, config.Height, test.format)
+		}
+		img, format, err := image.Decode(bytes.NewReader([]byte(test.input)))
+		if err != nil {
+			t.Fatalf("Decode(%q) : %v", test.input, err)
+		}
+		if format != test.format {
+			t.Errorf("Decode(%q) : format %s, %s attendu", test.input, format, test.format)
+		}
+		if got := test.model.Convert(img.At(1, 0)); got != test.want {
+			t.Errorf("Decode(%q) : pixel (1, 0) vaut %v, %v attendu", test.input, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package pam
+
+import (
+	"Netpbm/pbm"
+	"Netpbm/pgm"
+	"Netpbm/ppm"
+	"fmt"
+)
+
+// FromPBM crée une image PAM BLACKANDWHITE à partir d'une image PBM.
+// Dans une image PAM, 0 représente le noir et 1 le blanc, à l'inverse du format PBM.
+func FromPBM(src *pbm.PBM) *PAM {
+	width, height := src.Size()
+	pam := NewPAM(width, height, 1, 1, BlackAndWhite)
+	for y := 0; y < height; y++ {
+		row := pam.row(y)
+		for x := range row {
+			if !src.At(x, y) {
+				row[x] = 1
+			}
+		}
+	}
+	pam.comments = src.Comments()
+	return pam
+}
+
+// FromPGM crée une image PAM GRAYSCALE de même valeur maximale à partir d'une image PGM.
+func FromPGM(src *pgm.PGM) *PAM {
+	width, height := src.Size()
+	pam := NewPAM(width, height, 1, src.MaxValue(), Grayscale)
+	pix, stride := src.Pix(), src.Stride()
+	for y := 0; y < height; y++ {
+		copy(pam.row(y), pix[y*stride:y*stride+width])
+	}
+	pam.comments = src.Comments()
+	return pam
+}
+
+// FromPPM crée une image PAM RGB de même valeur maximale à partir d'une image PPM.
+func FromPPM(src *ppm.PPM) *PAM {
+	width, height := src.Size()
+	pam := NewPAM(width, height, 3, src.MaxValue(), RGB)
+	pix, stride := src.Pix(), src.Stride()
+	for y := 0; y < height; y++ {
+		row := pam.row(y)
+		for x, pixel := range pix[y*stride : y*stride+width] {
+			row[3*x], row[3*x+1], row[3*x+2] = pixel.R, pixel.G, pixel.B
+		}
+	}
+	pam.comments = src.Comments()
+	return pam
+}
+
+// PBM convertit une image PAM BLACKANDWHITE en image PBM (P4).
+func (pam *PAM) PBM() (*pbm.PBM, error) {
+	if err := pam.checkTupleType(BlackAndWhite); err != nil {
+		return nil, err
+	}
+	dst := pbm.NewPBM(pam.width, pam.height)
+	for y := 0; y < pam.height; y++ {
+		for x, val := range pam.row(y) {
+			if val == 0 {
+				dst.Set(x, y, true)
+			}
+		}
+	}
+	dst.SetMagicNumber("P4")
+	dst.SetComments(pam.comments)
+	return dst, nil
+}
+
+// PGM convertit une image PAM GRAYSCALE en image PGM (P5) de même valeur maximale.
+func (pam *PAM) PGM() (*pgm.PGM, error) {
+	if err := pam.checkTupleType(Grayscale); err != nil {
+		return nil, err
+	}
+	dst := pgm.NewPGM(pam.width, pam.height)
+	dst.SetMaxValue16(uint16(pam.max))
+	pix, stride := dst.Pix(), dst.Stride()
+	for y := 0; y < pam.height; y++ {
+		copy(pix[y*stride:y*stride+pam.width], pam.row(y))
+	}
+	dst.SetMagicNumber("P5")
+	dst.SetComments(pam.comments)
+	return dst, nil
+}
+
+// PPM convertit une image PAM RGB en image PPM (P6) de même valeur maximale.
+func (pam *PAM) PPM() (*ppm.PPM, error) {
+	if err := pam.checkTupleType(RGB); err != nil {
+		return nil, err
+	}
+	dst := ppm.NewPPM(pam.width, pam.height)
+	dst.SetMaxValue16(uint16(pam.max))
+	pix, stride := dst.Pix(), dst.Stride()
+	for y := 0; y < pam.height; y++ {
+		row := pam.row(y)
+		for x := range pix[y*stride : y*stride+pam.width] {
+			pix[y*stride+x] = ppm.Pixel16{R: row[3*x], G: row[3*x+1], B: row[3*x+2]}
+		}
+	}
+	dst.SetMagicNumber("P6")
+	dst.SetComments(pam.comments)
+	return dst, nil
+}
+
+// checkTupleType vérifie que l'image a le type de tuple attendu et la profondeur correspondante,
+// afin que la conversion ne perde aucune information.
+func (pam *PAM) checkTupleType(tupleType string) error {
+	if pam.tupleType != tupleType || pam.depth != tupleTypes[tupleType].depth {
+		return fmt.Errorf("conversion impossible sans perte : type de tuple %s attendu, %q de profondeur %d trouvé", tupleType, pam.tupleType, pam.depth)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package pam
+
+import (
+	"Netpbm/header"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+)
+
+// Le format P7 est enregistré auprès du paquet image, de sorte qu'un import
+// de ce paquet suffit pour qu'image.Decode reconnaisse les fichiers PAM.
+func init() {
+	image.RegisterFormat("pam", "P7", decodeImage, DecodeConfig)
+}
+
+// decodeImage lit une image PAM depuis r et renvoie son adaptateur image.Image.
+func decodeImage(r io.Reader) (image.Image, error) {
+	pam, err := Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	return pam.Image(), nil
+}
+
+// DecodeConfig renvoie le modèle de couleur et les dimensions d'une image PAM
+// en ne lisant que son en-tête.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	pam, err := readHeader(header.NewReader(r), header.Limits{})
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: pam.Image().ColorModel(), Width: pam.width, Height: pam.height}, nil
+}
+
+// Image adapte une image PAM aux interfaces image.Image et draw.Image.
+// Les images en niveaux de gris sont exposées en color.Gray ou color.Gray16, les images
+// RGB en color.RGBA ou color.RGBA64 et les images avec opacité en color.NRGBA ou
+// color.NRGBA64, la variante 16 bits étant choisie si la valeur maximale dépasse 255.
+type Image struct {
+	pam *PAM
+}
+
+var _ draw.Image = (*Image)(nil)
+
+// Image renvoie un adaptateur qui partage les pixels de l'image PAM.
+func (pam *PAM) Image() *Image {
+	return &Image{pam: pam}
+}
+
+// PAM renvoie l'image PAM sous-jacente.
+func (img *Image) PAM() *PAM {
+	return img.pam
+}
+
+// layout renvoie le nombre d'échantillons de couleur (1 pour les niveaux de gris, 3 pour RGB)
+// et indique s'ils sont suivis d'une opacité. Pour un type de tuple non standard, ou dont la
+// profondeur ne correspond pas, la disposition est déduite de la profondeur.
+func (pam *PAM) layout() (colors int, alpha bool) {
+	if t, ok := tupleTypes[pam.tupleType]; ok && t.depth == pam.depth {
+		if pam.HasAlpha() {
+			return pam.depth - 1, true
+		}
+		return pam.depth, false
+	}
+	switch {
+	case pam.depth < 3:
+		return 1, pam.depth == 2
+	case pam.depth == 3:
+		return 3, false
+	}
+	return 3, true
+}
+
+// ColorModel renvoie le modèle de couleur de l'image.
+func (img *Image) ColorModel() color.Model {
+	colors, alpha := img.pam.layout()
+	wide := img.pam.max > 255
+	switch {
+	case alpha && wide:
+		return color.NRGBA64Model
+	case alpha:
+		return color.NRGBAModel
+	case colors == 1 && wide:
+		return color.Gray16Model
+	case colors == 1:
+		return color.GrayModel
+	case wide:
+		return color.RGBA64Model
+	}
+	return color.RGBAModel
+}
+
+// Bounds renvoie le rectangle occupé par l'image.
+func (img *Image) Bounds() image.Rectangle {
+	return image.Rect(0, 0, img.pam.width, img.pam.height)
+}
+
+// At renvoie la couleur du pixel en (x, y).
+func (img *Image) At(x, y int) color.Color {
+	if !(image.Point{X: x, Y: y}.In(img.Bounds())) || img.pam.max < 1 {
+		return img.ColorModel().Convert(color.Transparent)
+	}
+	tuple, max := img.pam.At(x, y), img.pam.max
+	colors, alpha := img.pam.layout()
+	r := header.ScaleSample(tuple[0], max, 65535)
+	g, b := r, r
+	if colors == 3 {
+		g, b = header.ScaleSample(tuple[1], max, 65535), header.ScaleSample(tuple[2], max, 65535)
+	}
+	a := uint16(65535)
+	if alpha {
+		a = header.ScaleSample(tuple[colors], max, 65535)
+	}
+	var c color.Color
+	switch {
+	case alpha:
+		c = color.NRGBA64{R: r, G: g, B: b, A: a}
+	case colors == 1:
+		c = color.Gray16{Y: r}
+	default:
+		c = color.RGBA64{R: r, G: g, B: b, A: a}
+	}
+	return img.ColorModel().Convert(c)
+}
+
+// Set définit la couleur du pixel en (x, y). Les coordonnées hors de l'image sont ignorées.
+// Sans échantillon d'opacité, la couleur est composée sur du noir.
+func (img *Image) Set(x, y int, c color.Color) {
+	if !(image.Point{X: x, Y: y}.In(img.Bounds())) || img.pam.max < 1 {
+		return
+	}
+	tuple, max := img.pam.At(x, y), img.pam.max
+	colors, alpha := img.pam.layout()
+	if alpha {
+		n := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+		tuple[colors] = header.ScaleSample(n.A, 65535, max)
+		// L'opacité étant conservée à part, la couleur est prise sans prémultiplication
+		c = color.RGBA64{R: n.R, G: n.G, B: n.B, A: 65535}
+	}
+	if colors == 1 {
+		tuple[0] = header.ScaleSample(color.Gray16Model.Convert(c).(color.Gray16).Y, 65535, max)
+		return
+	}
+	r, g, b, _ := c.RGBA()
+	tuple[0] = header.ScaleSample(uint16(r), 65535, max)
+	tuple[1] = header.ScaleSample(uint16(g), 65535, max)
+	tuple[2] = header.ScaleSample(uint16(b), 65535, max)
+}
+
+// FromImage crée une image PAM RGB_ALPHA à partir d'une image quelconque.
+// La valeur maximale vaut 65535 si l'image source est en 16 bits, 255 sinon.
+func FromImage(src image.Image) *PAM {
+	bounds := src.Bounds()
+	max := uint16(255)
+	switch src.ColorModel() {
+	case color.Gray16Model, color.RGBA64Model, color.NRGBA64Model:
+		max = 65535
+	}
+	pam := NewPAM(bounds.Dx(), bounds.Dy(), 4, max, RGBAlpha)
+	img := pam.Image()
+	for y := 0; y < pam.height; y++ {
+		for x := 0; x < pam.width; x++ {
+			img.Set(x, y, src.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return pam
+}
@@ -0,0 +1,383 @@
+package pam
+
+import (
+	"Netpbm/common"
+	"Netpbm/header"
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Types de tuple standard de la spécification PAM.
+const (
+	BlackAndWhite      = "BLACKANDWHITE"
+	Grayscale          = "GRAYSCALE"
+	RGB                = "RGB"
+	BlackAndWhiteAlpha = "BLACKANDWHITE_ALPHA"
+	GrayscaleAlpha     = "GRAYSCALE_ALPHA"
+	RGBAlpha           = "RGB_ALPHA"
+)
+
+// tupleTypes associe à chaque type de tuple standard sa profondeur et, pour les
+// images noir et blanc, la seule valeur maximale autorisée.
+var tupleTypes = map[string]struct{ depth, max int }{
+	BlackAndWhite:      {1, 1},
+	Grayscale:          {1, 0},
+	RGB:                {3, 0},
+	BlackAndWhiteAlpha: {2, 1},
+	GrayscaleAlpha:     {2, 0},
+	RGBAlpha:           {4, 0},
+}
+
+// PAM représente une image PAM (P7), dont chaque pixel est un tuple de depth échantillons.
+// Les échantillons sont stockés sur 16 bits dans un tampon contigu où l'échantillon c
+// du pixel (x, y) se trouve à l'indice y*stride + x*depth + c.
+type PAM struct {
+	pix           []uint16
+	stride        int
+	width, height int
+	depth         int
+	max           int
+	tupleType     string
+	magicNumber   string
+	comments      []string
+}
+
+var _ common.Image = (*PAM)(nil)
+
+// NewPAM crée une nouvelle image PAM dont tous les échantillons valent 0.
+func NewPAM(width, height, depth int, max uint16, tupleType string) *PAM {
+	return &PAM{
+		pix:         make([]uint16, width*height*depth),
+		stride:      width * depth,
+		width:       width,
+		height:      height,
+		depth:       depth,
+		max:         int(max),
+		tupleType:   tupleType,
+		magicNumber: "P7",
+	}
+}
+
+// ReadPAM lit une image PAM à partir d'un fichier et renvoie une structure qui représente l'image.
+func ReadPAM(filename string) (*PAM, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Decode(file)
+}
+
+// Decode lit une image PAM depuis r et renvoie une structure qui représente l'image.
+// Les limites header.DefaultLimits sont appliquées.
+func Decode(r io.Reader) (*PAM, error) {
+	return DecodeWithLimits(r, header.DefaultLimits)
+}
+
+// DecodeWithLimits lit une image PAM depuis r en refusant, avant toute allocation,
+// les images qui dépassent limits. Les pixels sont alloués au fur et à mesure de la
+// lecture, de sorte qu'un fichier tronqué échoue sans réserver la taille annoncée.
+func DecodeWithLimits(r io.Reader, limits header.Limits) (*PAM, error) {
+	reader := header.NewReader(r)
+
+	pam, err := readHeader(reader, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	pam.pix, err = readRaw(reader, pam.stride, pam.height, pam.max)
+	if err != nil {
+		return nil, err
+	}
+
+	return pam, nil
+}
+
+// readHeader lit l'en-tête d'une image PAM et renvoie une image sans pixels si elle respecte limits.
+// Comme pour Validate, un type de tuple standard impose sa profondeur et, pour les images
+// noir et blanc, sa valeur maximale.
+func readHeader(reader *header.Reader, limits header.Limits) (*PAM, error) {
+	h, err := header.Read(reader, "P7")
+	if err != nil {
+		return nil, err
+	}
+	if err := limits.Check(reader, h); err != nil {
+		return nil, err
+	}
+	if t, ok := tupleTypes[h.TupleType]; ok {
+		if h.Depth != t.depth {
+			return nil, reader.NewError("profondeur", header.ErrInvalidValue, fmt.Sprintf("%d pour le type de tuple %s", t.depth, h.TupleType), strconv.Itoa(h.Depth))
+		}
+		if t.max > 0 && h.MaxValue != t.max {
+			return nil, reader.NewError("valeur maximale", header.ErrInvalidValue, fmt.Sprintf("%d pour le type de tuple %s", t.max, h.TupleType), strconv.Itoa(h.MaxValue))
+		}
+	}
+	return &PAM{
+		stride:      h.Width * h.Depth,
+		width:       h.Width,
+		height:      h.Height,
+		depth:       h.Depth,
+		max:         h.MaxValue,
+		tupleType:   h.TupleType,
+		magicNumber: h.MagicNumber,
+		comments:    h.Comments,
+	}, nil
+}
+
+// readRaw lit les échantillons d'une image PAM, ligne par ligne, à raison d'un octet par
+// échantillon, ou de deux octets en gros-boutiste si la valeur maximale dépasse 255.
+func readRaw(reader *header.Reader, stride, height, max int) ([]uint16, error) {
+	var pix []uint16
+	raw := make([]byte, header.SampleSize(max)*stride)
+	for y := 0; y < height; y++ {
+		pix = slices.Grow(pix, stride)[:len(pix)+stride]
+		if err := readRawRow(reader, raw, pix[y*stride:(y+1)*stride], max); err != nil {
+			return nil, err
+		}
+	}
+	return pix, nil
+}
+
+// readRawRow lit une ligne d'échantillons d'une image PAM, en utilisant raw comme tampon.
+func readRawRow(reader *header.Reader, raw []byte, row []uint16, max int) error {
+	size := header.SampleSize(max)
+	if err := reader.ReadFull(raw, "pixels"); err != nil {
+		return err
+	}
+	for i := range row {
+		if size == 1 {
+			row[i] = uint16(raw[i])
+		} else {
+			row[i] = binary.BigEndian.Uint16(raw[2*i:])
+		}
+		if int(row[i]) > max {
+			err := reader.NewError("pixels", header.ErrInvalidValue, fmt.Sprintf("entier entre 0 et %d", max), fmt.Sprint(row[i]))
+			err.Offset -= int64(len(raw) - size*i)
+			return err
+		}
+	}
+	return nil
+}
+
+// Size renvoie la largeur et la hauteur de l'image.
+func (pam *PAM) Size() (int, int) {
+	return pam.width, pam.height
+}
+
+// Depth renvoie le nombre d'échantillons par pixel.
+func (pam *PAM) Depth() int {
+	return pam.depth
+}
+
+// MaxValue renvoie la valeur maximale des échantillons.
+func (pam *PAM) MaxValue() uint16 {
+	return uint16(pam.max)
+}
+
+// TupleType renvoie le type de tuple de l'image, par exemple RGB ou GRAYSCALE_ALPHA.
+func (pam *PAM) TupleType() string {
+	return pam.tupleType
+}
+
+// SetTupleType définit le type de tuple de l'image sans modifier les échantillons.
+func (pam *PAM) SetTupleType(tupleType string) {
+	pam.tupleType = tupleType
+}
+
+// HasAlpha indique si le dernier échantillon de chaque pixel est une opacité,
+// c'est-à-dire si le type de tuple se termine par _ALPHA.
+func (pam *PAM) HasAlpha() bool {
+	return strings.HasSuffix(pam.tupleType, "_ALPHA")
+}
+
+// At renvoie les échantillons du pixel en (x, y), sans copie : les modifications
+// de la tranche renvoyée sont visibles dans l'image.
+func (pam *PAM) At(x, y int) []uint16 {
+	return pam.row(y)[x*pam.depth : (x+1)*pam.depth]
+}
+
+// Set définit les échantillons du pixel en (x, y). tuple doit contenir Depth() échantillons.
+func (pam *PAM) Set(x, y int, tuple []uint16) {
+	if len(tuple) != pam.depth {
+		panic(fmt.Sprintf("pam: %d échantillons pour une profondeur de %d", len(tuple), pam.depth))
+	}
+	copy(pam.At(x, y), tuple)
+}
+
+// row renvoie la ligne y de l'image, qui partage le tampon des échantillons.
+func (pam *PAM) row(y int) []uint16 {
+	return pam.pix[y*pam.stride : y*pam.stride+pam.width*pam.depth]
+}
+
+// Pix renvoie le tampon contigu des échantillons, sans copie : l'échantillon c du
+// pixel (x, y) se trouve à l'indice y*Stride() + x*Depth() + c.
+func (pam *PAM) Pix() []uint16 {
+	return pam.pix
+}
+
+// Stride renvoie l'écart, en nombre d'échantillons, entre deux lignes consécutives de Pix.
+func (pam *PAM) Stride() int {
+	return pam.stride
+}
+
+// Validate vérifie la cohérence de l'image : nombre magique P7, dimensions positives,
+// tampon assez grand pour le pas annoncé, valeur maximale comprise entre 1 et 65535,
+// aucun échantillon au-delà de celle-ci et, pour les types de tuple standard,
+// profondeur et valeur maximale conformes à la spécification.
+func (pam *PAM) Validate() error {
+	if pam.magicNumber != "P7" {
+		return fmt.Errorf("nombre magique non valide : %q", pam.magicNumber)
+	}
+	if pam.width < 0 || pam.height < 0 || pam.depth < 1 {
+		return fmt.Errorf("dimensions non valides : %d × %d × %d", pam.width, pam.height, pam.depth)
+	}
+	if pam.height > 0 && (pam.stride < pam.width*pam.depth || len(pam.pix) < (pam.height-1)*pam.stride+pam.width*pam.depth) {
+		return fmt.Errorf("%d échantillons avec un pas de %d pour une image de %d × %d × %d", len(pam.pix), pam.stride, pam.width, pam.height, pam.depth)
+	}
+	if pam.max < 1 || pam.max > 65535 {
+		return fmt.Errorf("valeur maximale non valide : %d", pam.max)
+	}
+	if strings.ContainsAny(pam.tupleType, "\r\n") {
+		return fmt.Errorf("type de tuple non valide : %q", pam.tupleType)
+	}
+	if t, ok := tupleTypes[pam.tupleType]; ok {
+		if pam.depth != t.depth {
+			return fmt.Errorf("profondeur %d non valide pour le type de tuple %s, %d attendue", pam.depth, pam.tupleType, t.depth)
+		}
+		if t.max > 0 && pam.max != t.max {
+			return fmt.Errorf("valeur maximale %d non valide pour le type de tuple %s, %d attendue", pam.max, pam.tupleType, t.max)
+		}
+	}
+	for y := 0; y < pam.height; y++ {
+		for i, val := range pam.row(y) {
+			if int(val) > pam.max {
+				return fmt.Errorf("l'échantillon %d du pixel (%d, %d) vaut %d, au-delà de la valeur maximale %d", i%pam.depth, i/pam.depth, y, val, pam.max)
+			}
+		}
+	}
+	return nil
+}
+
+// Save enregistre l'image PAM dans un fichier et renvoie une erreur en cas de problème.
+// Une erreur est renvoyée, sans créer le fichier, si Validate échoue.
+func (pam *PAM) Save(filename string) error {
+	if err := pam.Validate(); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := pam.encode(file); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Encode écrit l'image PAM dans w.
+// Une erreur est renvoyée, sans rien écrire, si Validate échoue.
+func (pam *PAM) Encode(w io.Writer) error {
+	if err := pam.Validate(); err != nil {
+		return err
+	}
+	return pam.encode(w)
+}
+
+// encode écrit l'en-tête et les échantillons de l'image PAM dans w.
+func (pam *PAM) encode(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+	writeHeader(writer, pam)
+	size := header.SampleSize(pam.max)
+	raw := make([]byte, size*pam.width*pam.depth)
+	for y := 0; y < pam.height; y++ {
+		for i, val := range pam.row(y) {
+			if size == 1 {
+				raw[i] = uint8(val)
+			} else {
+				binary.BigEndian.PutUint16(raw[2*i:], val)
+			}
+		}
+		writer.Write(raw)
+	}
+	return writer.Flush()
+}
+
+// writeHeader écrit l'en-tête d'une image PAM.
+// Les commentaires sont écrits juste après le nombre magique.
+func writeHeader(writer *bufio.Writer, pam *PAM) {
+	fmt.Fprintf(writer, "%s\n", pam.magicNumber)
+	header.WriteComments(writer, pam.comments)
+	fmt.Fprintf(writer, "WIDTH %d\nHEIGHT %d\nDEPTH %d\nMAXVAL %d\n", pam.width, pam.height, pam.depth, pam.max)
+	if pam.tupleType != "" {
+		fmt.Fprintf(writer, "TUPLTYPE %s\n", pam.tupleType)
+	}
+	fmt.Fprintf(writer, "ENDHDR\n")
+}
+
+// Invert inverse les couleurs de l'image PAM. L'opacité des types de tuple _ALPHA est conservée.
+func (pam *PAM) Invert() {
+	colors := pam.depth
+	if pam.HasAlpha() {
+		colors--
+	}
+	max := uint16(pam.max)
+	for y := 0; y < pam.height; y++ {
+		row := pam.row(y)
+		for i := range row {
+			if i%pam.depth < colors {
+				row[i] = max - row[i]
+			}
+		}
+	}
+}
+
+// Flip retourne l'image PAM horizontalement.
+func (pam *PAM) Flip() {
+	for y := 0; y < pam.height; y++ {
+		row := pam.row(y)
+		for x := 0; x < pam.width/2; x++ {
+			left := row[x*pam.depth : (x+1)*pam.depth]
+			right := row[(pam.width-x-1)*pam.depth : (pam.width-x)*pam.depth]
+			for c := range left {
+				left[c], right[c] = right[c], left[c]
+			}
+		}
+	}
+}
+
+// Flop retourne l'image PAM verticalement.
+func (pam *PAM) Flop() {
+	for y := 0; y < pam.height/2; y++ {
+		top, bottom := pam.row(y), pam.row(pam.height-y-1)
+		for i := range top {
+			top[i], bottom[i] = bottom[i], top[i]
+		}
+	}
+}
+
+// SetMagicNumber définit le nombre magique de l'image PAM. Seul P7 est valide ;
+// la méthode existe pour satisfaire common.Image.
+func (pam *PAM) SetMagicNumber(magicNumber string) {
+	pam.magicNumber = magicNumber
+}
+
+// Comments renvoie une copie des commentaires de l'en-tête, sans le # initial.
+func (pam *PAM) Comments() []string {
+	return slices.Clone(pam.comments)
+}
+
+// SetComments définit les commentaires écrits dans l'en-tête par Save et Encode.
+// Un commentaire contenant des sauts de ligne est écrit sur plusieurs lignes.
+func (pam *PAM) SetComments(comments []string) {
+	pam.comments = slices.Clone(comments)
+}
@@ -0,0 +1,138 @@
+package pam
+
+import (
+	"Netpbm/pbm"
+	"Netpbm/pgm"
+	"Netpbm/ppm"
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	input := "P7\n# commentaire\nWIDTH 2\nHEIGHT 1\nDEPTH 2\nMAXVAL 1000\n" +
+		"TUPLTYPE GRAYSCALE\nTUPLTYPE  _ALPHA\nENDHDR\n\x00\x01\x03\xe8\x03\xe7\x00\x00"
+	pam, err := Decode(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	width, height := pam.Size()
+	if width != 2 || height != 1 || pam.Depth() != 2 || pam.MaxValue() != 1000 {
+		t.Errorf("%d × %d × %d, maxval %d ; 2 × 1 × 2, maxval 1000 attendu", width, height, pam.Depth(), pam.MaxValue())
+	}
+	// Les lignes TUPLTYPE successives sont concaténées, séparées par une espace
+	if pam.TupleType() != "GRAYSCALE _ALPHA" {
+		t.Errorf("type de tuple %q, \"GRAYSCALE _ALPHA\" attendu", pam.TupleType())
+	}
+	if want := []uint16{1, 1000, 999, 0}; !slices.Equal(pam.Pix(), want) {
+		t.Errorf("échantillons %v, %v attendus", pam.Pix(), want)
+	}
+	if !slices.Equal(pam.Comments(), []string{"commentaire"}) {
+		t.Errorf("commentaires %q", pam.Comments())
+	}
+}
+
+func TestEncode(t *testing.T) {
+	pam := NewPAM(2, 1, 4, 255, RGBAlpha)
+	pam.Set(0, 0, []uint16{1, 2, 3, 255})
+	pam.Set(1, 0, []uint16{'\n', ' ', '#', 0})
+	pam.SetComments([]string{"note"})
+	var buf bytes.Buffer
+	if err := pam.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "P7\n# note\nWIDTH 2\nHEIGHT 1\nDEPTH 4\nMAXVAL 255\nTUPLTYPE RGB_ALPHA\nENDHDR\n\x01\x02\x03\xff\n #\x00"
+	if buf.String() != want {
+		t.Fatalf("%q, %q attendu", buf.String(), want)
+	}
+	decoded, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.TupleType() != RGBAlpha || !slices.Equal(decoded.Pix(), pam.Pix()) {
+		t.Errorf("relu : %s %v, %s %v attendu", decoded.TupleType(), decoded.Pix(), RGBAlpha, pam.Pix())
+	}
+}
+
+func TestBlackAndWhite(t *testing.T) {
+	// Un pixel PBM à true est noir ; en PAM BLACKANDWHITE, le noir vaut 0
+	src := pbm.NewPBM(3, 1)
+	src.Set(0, 0, true)
+	src.Set(2, 0, true)
+	pam := FromPBM(src)
+	if pam.TupleType() != BlackAndWhite || pam.MaxValue() != 1 || !slices.Equal(pam.Pix(), []uint16{0, 1, 0}) {
+		t.Errorf("FromPBM : %s, maxval %d, %v ; BLACKANDWHITE, maxval 1, [0 1 0] attendu", pam.TupleType(), pam.MaxValue(), pam.Pix())
+	}
+
+	pam = NewPAM(3, 1, 1, 1, BlackAndWhite)
+	pam.Set(1, 0, []uint16{1})
+	dst, err := pam.PBM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dst.At(0, 0) || dst.At(1, 0) || !dst.At(2, 0) {
+		t.Errorf("PBM : pixels %v %v %v, true false true attendu", dst.At(0, 0), dst.At(1, 0), dst.At(2, 0))
+	}
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	bitmap := pbm.NewPBM(70, 3)
+	gray := pgm.NewPGM(5, 2)
+	gray.SetMaxValue16(4095)
+	color := ppm.NewPPM(4, 3)
+	color.SetMaxValue16(1000)
+	for x := 0; x < 70; x++ {
+		bitmap.Set(x, x%3, x%5 != 0)
+	}
+	for x := 0; x < 5; x++ {
+		gray.Set16(x, 1, uint16(x*1000))
+	}
+	for x := 0; x < 4; x++ {
+		color.Set16(x, 2, ppm.Pixel16{R: uint16(x), G: 1000, B: uint16(x * 300)})
+	}
+	for _, img := range []interface{ SetComments([]string) }{bitmap, gray, color} {
+		img.SetComments([]string{"source"})
+	}
+
+	// Chaque conversion passe aussi par l'encodage PAM
+	reencode := func(pam *PAM) *PAM {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := pam.Encode(&buf); err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := Decode(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return decoded
+	}
+
+	gotBitmap, err := reencode(FromPBM(bitmap)).PBM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(gotBitmap.Pix(), bitmap.Pix()) || !slices.Equal(gotBitmap.Comments(), bitmap.Comments()) {
+		t.Error("PBM → PAM → PBM modifie l'image")
+	}
+	gotGray, err := reencode(FromPGM(gray)).PGM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotGray.MaxValue() != 4095 || !slices.Equal(gotGray.Pix(), gray.Pix()) || !slices.Equal(gotGray.Comments(), gray.Comments()) {
+		t.Errorf("PGM → PAM → PGM : maxval %d, %v ; %v attendu", gotGray.MaxValue(), gotGray.Pix(), gray.Pix())
+	}
+	gotColor, err := reencode(FromPPM(color)).PPM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotColor.MaxValue() != 1000 || !slices.Equal(gotColor.Pix(), color.Pix()) || !slices.Equal(gotColor.Comments(), color.Comments()) {
+		t.Errorf("PPM → PAM → PPM : maxval %d, %v ; %v attendu", gotColor.MaxValue(), gotColor.Pix(), color.Pix())
+	}
+
+	// Une conversion qui perdrait de l'information est refusée
+	if _, err := FromPGM(gray).PPM(); err == nil {
+		t.Error("GRAYSCALE converti en PPM")
+	}
+}
@@ -0,0 +1,46 @@
+package pam
+
+import (
+	"Netpbm/header"
+	"io"
+)
+
+// Reader lit successivement les images PAM concaténées dans un même flux.
+type Reader struct {
+	reader *header.Reader
+	limits header.Limits
+}
+
+// NewReader renvoie un Reader qui lit les images de r avec les limites header.DefaultLimits.
+func NewReader(r io.Reader) *Reader {
+	return NewReaderWithLimits(r, header.DefaultLimits)
+}
+
+// NewReaderWithLimits renvoie un Reader qui applique limits à chacune des images de r.
+func NewReaderWithLimits(r io.Reader, limits header.Limits) *Reader {
+	return &Reader{reader: header.NewReader(r), limits: limits}
+}
+
+// Next lit l'image suivante du flux. io.EOF est renvoyé lorsqu'il ne reste plus
+// que des caractères d'espacement ou des commentaires ; toute autre erreur interrompt la lecture.
+func (r *Reader) Next() (*PAM, error) {
+	if err := r.reader.SkipSpace(); err != nil {
+		return nil, err
+	}
+	return DecodeWithLimits(r.reader, r.limits)
+}
+
+// Writer écrit plusieurs images PAM à la suite dans un même flux.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter renvoie un Writer qui écrit dans w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write ajoute l'image PAM au flux.
+func (w *Writer) Write(pam *PAM) error {
+	return pam.Encode(w.w)
+}
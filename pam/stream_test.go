@@ -0,0 +1,39 @@
+package pam
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	gray := NewPAM(2, 1, 1, 255, "GRAYSCALE")
+	gray.Set(0, 0, []uint16{'\n'})
+	gray.Set(1, 0, []uint16{' '})
+	rgba := NewPAM(1, 2, 4, 65535, "RGB_ALPHA")
+	rgba.Set(0, 1, []uint16{1, 2, 3, 65535})
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, pam := range []*PAM{gray, rgba} {
+		if err := w.Write(pam); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf.WriteString("\n# fin du flux\n")
+
+	r := NewReader(&buf)
+	for i, want := range []*PAM{gray, rgba} {
+		got, err := r.Next()
+		if err != nil {
+			t.Fatalf("image %d : %v", i, err)
+		}
+		if got.TupleType() != want.TupleType() || got.Depth() != want.Depth() || !slices.Equal(got.Pix(), want.Pix()) {
+			t.Errorf("image %d : %s %v, %s %v attendu", i, got.TupleType(), got.Pix(), want.TupleType(), want.Pix())
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("%v, io.EOF attendu", err)
+	}
+}
@@ -0,0 +1,151 @@
+package pbm
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// wordsPerRow renvoie le nombre de mots de 64 bits nécessaires pour une ligne de width pixels.
+func wordsPerRow(width int) int {
+	return (width + 63) / 64
+}
+
+// bitMask renvoie le masque du pixel d'abscisse x dans son mot.
+func bitMask(x int) uint64 {
+	return 1 << (63 - uint(x%64))
+}
+
+// lastWordMask renvoie le masque des pixels utiles du dernier mot d'une ligne de width pixels.
+func lastWordMask(width int) uint64 {
+	if width%64 == 0 {
+		return ^uint64(0)
+	}
+	return ^uint64(0) << (64 - uint(width%64))
+}
+
+// NewPBM crée une nouvelle image PBM (P1) blanche avec la largeur et la hauteur spécifiées.
+func NewPBM(width, height int) *PBM {
+	stride := wordsPerRow(width)
+	return &PBM{
+		bits:        make([]uint64, stride*height),
+		stride:      stride,
+		width:       width,
+		height:      height,
+		magicNumber: "P1",
+	}
+}
+
+// packBools compacte une ligne de pixels dans words.
+func packBools(words []uint64, row []bool) {
+	clear(words)
+	for x, pixel := range row {
+		if pixel {
+			words[x/64] |= bitMask(x)
+		}
+	}
+}
+
+// unpackBools décompacte les width premiers pixels de words dans row.
+func unpackBools(row []bool, words []uint64) {
+	for x := range row {
+		row[x] = words[x/64]&bitMask(x) != 0
+	}
+}
+
+// packBytes compacte une ligne P4 de width pixels dans words, en ignorant les bits de remplissage.
+func packBytes(words []uint64, raw []byte, width int) {
+	clear(words)
+	for i, b := range raw {
+		words[i/8] |= uint64(b) << (56 - 8*uint(i%8))
+	}
+	if len(words) > 0 {
+		words[len(words)-1] &= lastWordMask(width)
+	}
+}
+
+// unpackBytes écrit dans raw la ligne P4 correspondant à words.
+func unpackBytes(raw []byte, words []uint64) {
+	for i := range raw {
+		raw[i] = byte(words[i/8] >> (56 - 8*uint(i%8)))
+	}
+}
+
+// Invert inverse les couleurs de l'image PBM, 64 pixels à la fois.
+func (pbm *PBM) Invert() {
+	if pbm.stride == 0 {
+		return
+	}
+	mask := lastWordMask(pbm.width)
+	for y := 0; y < pbm.height; y++ {
+		row := pbm.row(y)
+		for i := range row {
+			row[i] = ^row[i]
+		}
+		row[len(row)-1] &= mask
+	}
+}
+
+// Flip retourne l'image PBM horizontalement, en inversant l'ordre des mots et des bits
+// de chaque ligne puis en décalant la ligne pour éliminer le remplissage.
+func (pbm *PBM) Flip() {
+	if pbm.stride == 0 {
+		return
+	}
+	padding := uint(pbm.stride*64 - pbm.width)
+	for y := 0; y < pbm.height; y++ {
+		row := pbm.row(y)
+		for i, j := 0, len(row)-1; i <= j; i, j = i+1, j-1 {
+			row[i], row[j] = bits.Reverse64(row[j]), bits.Reverse64(row[i])
+		}
+		if padding > 0 {
+			for i := range row {
+				row[i] <<= padding
+				if i+1 < len(row) {
+					row[i] |= row[i+1] >> (64 - padding)
+				}
+			}
+		}
+	}
+}
+
+// Flop retourne l'image PBM verticalement.
+func (pbm *PBM) Flop() {
+	for y := 0; y < pbm.height/2; y++ {
+		top, bottom := pbm.row(y), pbm.row(pbm.height-y-1)
+		for i := range top {
+			top[i], bottom[i] = bottom[i], top[i]
+		}
+	}
+}
+
+// And remplace chaque pixel de l'image par le ET logique avec le pixel correspondant
+// de other : un pixel reste noir s'il est noir dans les deux images.
+func (pbm *PBM) And(other *PBM) error {
+	return pbm.combine(other, func(a, b uint64) uint64 { return a & b })
+}
+
+// Or remplace chaque pixel de l'image par le OU logique avec le pixel correspondant
+// de other : un pixel devient noir s'il est noir dans l'une des deux images.
+func (pbm *PBM) Or(other *PBM) error {
+	return pbm.combine(other, func(a, b uint64) uint64 { return a | b })
+}
+
+// Xor remplace chaque pixel de l'image par le OU exclusif avec le pixel correspondant
+// de other : un pixel devient noir s'il diffère entre les deux images.
+func (pbm *PBM) Xor(other *PBM) error {
+	return pbm.combine(other, func(a, b uint64) uint64 { return a ^ b })
+}
+
+// combine applique op mot à mot entre l'image et other, qui doivent avoir les mêmes dimensions.
+func (pbm *PBM) combine(other *PBM, op func(a, b uint64) uint64) error {
+	if pbm.width != other.width || pbm.height != other.height {
+		return errors.New("les images n'ont pas les mêmes dimensions")
+	}
+	for y := 0; y < pbm.height; y++ {
+		row, otherRow := pbm.row(y), other.row(y)
+		for i := range row {
+			row[i] = op(row[i], otherRow[i])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package pbm
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// widths couvre les lignes vides, un seul mot partiel ou plein et plusieurs mots
+// dont le dernier est partiel.
+var widths = []int{0, 1, 63, 64, 65, 130}
+
+// reference est une image PBM naïve, un booléen par pixel, qui sert de référence
+// aux opérations sur les bits compactés.
+type reference [][]bool
+
+// randomImage renvoie une image aléatoire et la référence correspondante.
+func randomImage(rng *rand.Rand, width, height int) (*PBM, reference) {
+	pbm, ref := NewPBM(width, height), make(reference, height)
+	for y := range ref {
+		ref[y] = make([]bool, width)
+		for x := range ref[y] {
+			ref[y][x] = rng.Intn(2) == 1
+			pbm.Set(x, y, ref[y][x])
+		}
+	}
+	return pbm, ref
+}
+
+// check vérifie que pbm est valide et identique pixel à pixel à ref.
+func check(t *testing.T, op string, pbm *PBM, ref reference) {
+	t.Helper()
+	if err := pbm.Validate(); err != nil {
+		t.Fatalf("%s : %v", op, err)
+	}
+	for y := range ref {
+		for x, want := range ref[y] {
+			if got := pbm.At(x, y); got != want {
+				t.Fatalf("%s : pixel (%d, %d) vaut %v, %v attendu", op, x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestBitOperations(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, width := range widths {
+		for _, height := range []int{0, 1, 3} {
+			pbm, ref := randomImage(rng, width, height)
+			check(t, "Set", pbm, ref)
+
+			pbm.Invert()
+			for y := range ref {
+				for x := range ref[y] {
+					ref[y][x] = !ref[y][x]
+				}
+			}
+			check(t, "Invert", pbm, ref)
+
+			pbm.Flip()
+			for y := range ref {
+				for x := 0; x < width/2; x++ {
+					ref[y][x], ref[y][width-x-1] = ref[y][width-x-1], ref[y][x]
+				}
+			}
+			check(t, "Flip", pbm, ref)
+
+			pbm.Flop()
+			for y := 0; y < height/2; y++ {
+				ref[y], ref[height-y-1] = ref[height-y-1], ref[y]
+			}
+			check(t, "Flop", pbm, ref)
+
+			ops := []struct {
+				name    string
+				combine func(pbm, other *PBM) error
+				op      func(a, b bool) bool
+			}{
+				{"And", (*PBM).And, func(a, b bool) bool { return a && b }},
+				{"Or", (*PBM).Or, func(a, b bool) bool { return a || b }},
+				{"Xor", (*PBM).Xor, func(a, b bool) bool { return a != b }},
+			}
+			for _, op := range ops {
+				other, otherRef := randomImage(rng, width, height)
+				if err := op.combine(pbm, other); err != nil {
+					t.Fatalf("%s : %v", op.name, err)
+				}
+				for y := range ref {
+					for x := range ref[y] {
+						ref[y][x] = op.op(ref[y][x], otherRef[y][x])
+					}
+				}
+				check(t, op.name, pbm, ref)
+			}
+		}
+	}
+}
+
+func TestFlipFullRows(t *testing.T) {
+	// Une ligne entièrement noire reste entièrement noire, sans déborder dans le remplissage
+	for _, width := range widths {
+		pbm := NewPBM(width, 2)
+		pbm.Invert()
+		pbm.Flip()
+		ref := reference{make([]bool, width), make([]bool, width)}
+		for y := range ref {
+			for x := range ref[y] {
+				ref[y][x] = true
+			}
+		}
+		check(t, "Flip", pbm, ref)
+	}
+}
+
+func TestCombineSizeMismatch(t *testing.T) {
+	if err := NewPBM(64, 2).And(NewPBM(65, 2)); err == nil {
+		t.Error("And entre des images de largeurs différentes : aucune erreur")
+	}
+	if err := NewPBM(8, 2).Xor(NewPBM(8, 3)); err == nil {
+		t.Error("Xor entre des images de hauteurs différentes : aucune erreur")
+	}
+}
+
+func TestPackBytes(t *testing.T) {
+	for _, width := range widths {
+		raw := bytes.Repeat([]byte{0xff}, (width+7)/8)
+		words := make([]uint64, wordsPerRow(width))
+		// Les bits de remplissage du dernier octet, à 1 dans raw, doivent être ignorés
+		packBytes(words, raw, width)
+		if len(words) > 0 && words[len(words)-1] != lastWordMask(width) {
+			t.Errorf("largeur %d : dernier mot %#x, %#x attendu", width, words[len(words)-1], lastWordMask(width))
+		}
+		for i := 0; i+1 < len(words); i++ {
+			if words[i] != ^uint64(0) {
+				t.Errorf("largeur %d : mot %d vaut %#x", width, i, words[i])
+			}
+		}
+
+		got := make([]byte, len(raw))
+		unpackBytes(got, words)
+		want := bytes.Clone(raw)
+		if width%8 != 0 {
+			want[len(want)-1] = byte(0xff << (8 - width%8))
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("largeur %d : %x, %x attendu", width, got, want)
+		}
+	}
+}
+
+func TestBitsRoundTripP4(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, width := range widths {
+		pbm, ref := randomImage(rng, width, 3)
+		pbm.Flip()
+		pbm.Invert()
+		for y := range ref {
+			for x := 0; x < width/2; x++ {
+				ref[y][x], ref[y][width-x-1] = ref[y][width-x-1], ref[y][x]
+			}
+			for x := range ref[y] {
+				ref[y][x] = !ref[y][x]
+			}
+		}
+		pbm.SetMagicNumber("P4")
+
+		var buf bytes.Buffer
+		if err := pbm.Encode(&buf); err != nil {
+			t.Fatal(err)
+		}
+		// Les bits de remplissage écrits dans le fichier sont nuls
+		if rowBytes := (width + 7) / 8; width%8 != 0 {
+			data := buf.Bytes()
+			for y := 0; y < 3; y++ {
+				last := data[len(data)-(3-y)*rowBytes+rowBytes-1]
+				if last&(0xff>>(width%8)) != 0 {
+					t.Errorf("largeur %d, ligne %d : remplissage non nul dans %#x", width, y, last)
+				}
+			}
+		}
+		decoded, err := Decode(&buf)
+		if err != nil {
+			t.Fatalf("largeur %d : %v", width, err)
+		}
+		check(t, "Decode", decoded, ref)
+	}
+}
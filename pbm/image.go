@@ -0,0 +1,103 @@
+package pbm
+
+import (
+	"Netpbm/header"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+)
+
+// Les formats P1 et P4 sont enregistrés auprès du paquet image, de sorte
+// qu'un import de ce paquet suffit pour qu'image.Decode reconnaisse les fichiers PBM.
+func init() {
+	image.RegisterFormat("pbm", "P1", decodeImage, DecodeConfig)
+	image.RegisterFormat("pbm", "P4", decodeImage, DecodeConfig)
+}
+
+// decodeImage lit une image PBM depuis r et renvoie son adaptateur image.Image.
+func decodeImage(r io.Reader) (image.Image, error) {
+	pbm, err := Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	return pbm.Image(), nil
+}
+
+// DecodeConfig renvoie le modèle de couleur et les dimensions d'une image PBM
+// en ne lisant que son en-tête.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	pbm, err := readHeader(header.NewReader(r), header.Limits{})
+	if err != nil {
+		return image.Config{}, err
+	}
+	colorModel := BitModel
+	return image.Config{ColorModel: colorModel, Width: pbm.width, Height: pbm.height}, nil
+}
+
+// BitModel convertit une couleur quelconque en noir ou blanc selon sa luminance.
+var BitModel = color.ModelFunc(bitModel)
+
+func bitModel(c color.Color) color.Color {
+	if color.GrayModel.Convert(c).(color.Gray).Y < 128 {
+		return color.Black
+	}
+	return color.White
+}
+
+// Image adapte une image PBM aux interfaces image.Image et draw.Image.
+// Un pixel à true (1 dans le fichier) est noir, un pixel à false est blanc.
+type Image struct {
+	pbm *PBM
+}
+
+var _ draw.Image = (*Image)(nil)
+
+// Image renvoie un adaptateur qui partage les pixels de l'image PBM.
+func (pbm *PBM) Image() *Image {
+	return &Image{pbm: pbm}
+}
+
+// PBM renvoie l'image PBM sous-jacente.
+func (img *Image) PBM() *PBM {
+	return img.pbm
+}
+
+// ColorModel renvoie le modèle de couleur de l'image.
+func (img *Image) ColorModel() color.Model {
+	return BitModel
+}
+
+// Bounds renvoie le rectangle occupé par l'image.
+func (img *Image) Bounds() image.Rectangle {
+	return image.Rect(0, 0, img.pbm.width, img.pbm.height)
+}
+
+// At renvoie la couleur du pixel en (x, y).
+func (img *Image) At(x, y int) color.Color {
+	if img.pbm.At(x, y) {
+		return color.Black
+	}
+	return color.White
+}
+
+// Set définit la couleur du pixel en (x, y). Les coordonnées hors de l'image sont ignorées.
+func (img *Image) Set(x, y int, c color.Color) {
+	if !(image.Point{X: x, Y: y}.In(img.Bounds())) {
+		return
+	}
+	img.pbm.Set(x, y, BitModel.Convert(c) == color.Black)
+}
+
+// FromImage crée une image PBM (P1) à partir d'une image quelconque,
+// en seuillant la luminance de chaque pixel.
+func FromImage(src image.Image) *PBM {
+	bounds := src.Bounds()
+	pbm := NewPBM(bounds.Dx(), bounds.Dy())
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			pbm.Set(x, y, BitModel.Convert(src.At(bounds.Min.X+x, bounds.Min.Y+y)) == color.Black)
+		}
+	}
+	return pbm
+}
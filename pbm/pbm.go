@@ -1,88 +1,140 @@
 package pbm
 
 import (
-	"bufio"
-	"errors"
+	"Netpbm/common"
+	"Netpbm/header"
 	"fmt"
+	"io"
 	"os"
-	"strconv"
-	"strings"
+	"slices"
 )
 
 // PBM représente une image PBM.
+// Les pixels sont compactés à raison d'un bit par pixel dans des mots de 64 bits,
+// le bit de poids fort de chaque mot correspondant au pixel le plus à gauche.
+// Chaque ligne occupe stride mots et ses bits de remplissage valent toujours 0.
 type PBM struct {
-	data          [][]bool
+	bits          []uint64
+	stride        int
 	width, height int
 	magicNumber   string
+	comments      []string
 }
 
+var _ common.Image = (*PBM)(nil)
+
 // ReadPBM lit une image PBM à partir d'un fichier et renvoie une structure qui représente l'image.
 func ReadPBM(filename string) (*PBM, error) {
-	// Ouvrir le fichier
 	file, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	// Initialiser le scanner pour lire le fichier ligne par ligne
-	scanner := bufio.NewScanner(file)
+	return Decode(file)
+}
 
-	// Ignorer les commentaires au début du fichier
-	for scanner.Scan() {
-		if line := scanner.Text(); !strings.HasPrefix(line, "#") {
-			break
-		}
-	}
+// Decode lit une image PBM depuis r et renvoie une structure qui représente l'image.
+// Les limites header.DefaultLimits sont appliquées.
+func Decode(r io.Reader) (*PBM, error) {
+	return DecodeWithLimits(r, header.DefaultLimits)
+}
 
-	// Lire le nombre magique (P1 ou P4)
-	magicNumber := scanner.Text()
-	if magicNumber != "P1" && magicNumber != "P4" {
-		return nil, errors.New("type de fichier non pris en charge")
+// DecodeWithLimits lit une image PBM depuis r en refusant, avant toute allocation,
+// les images qui dépassent limits. Les pixels sont alloués au fur et à mesure de la
+// lecture, de sorte qu'un fichier tronqué échoue sans réserver la taille annoncée.
+func DecodeWithLimits(r io.Reader, limits header.Limits) (*PBM, error) {
+	reader := header.NewReader(r)
+
+	pbm, err := readHeader(reader, limits)
+	if err != nil {
+		return nil, err
 	}
 
-	// Lire les dimensions de l'image
-	scanner.Scan()
-	dimensions := strings.Fields(scanner.Text())
-	if len(dimensions) != 2 {
-		return nil, errors.New("dimensions d'image non valides")
+	if pbm.magicNumber == "P1" {
+		pbm.bits, err = readPlain(reader, pbm.width, pbm.height)
+	} else {
+		pbm.bits, err = readRaw(reader, pbm.width, pbm.height)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	// Convertir les dimensions en entiers (largeur et hauteur)
-	width, _ := strconv.Atoi(dimensions[0])
-	height, _ := strconv.Atoi(dimensions[1])
+	return pbm, nil
+}
 
-	// Initialiser une matrice pour stocker les valeurs des pixels
-	var data [][]bool
-	for scanner.Scan() {
-		// Ignorer les lignes de commentaire
-		line := scanner.Text()
-		if strings.HasPrefix(line, "#") || line == "" {
-			continue
+// readHeader lit l'en-tête d'une image PBM (nombre magique et dimensions) et renvoie
+// une image sans pixels si elle respecte limits.
+func readHeader(reader *header.Reader, limits header.Limits) (*PBM, error) {
+	h, err := header.Read(reader, "P1", "P4")
+	if err != nil {
+		return nil, err
+	}
+	if err := limits.Check(reader, h); err != nil {
+		return nil, err
+	}
+	return &PBM{
+		stride:      wordsPerRow(h.Width),
+		width:       h.Width,
+		height:      h.Height,
+		magicNumber: h.MagicNumber,
+		comments:    h.Comments,
+	}, nil
+}
+
+// readPlain lit les pixels d'une image P1, où chaque pixel est le caractère 0 ou 1,
+// et les renvoie compactés.
+func readPlain(reader *header.Reader, width, height int) ([]uint64, error) {
+	var bits []uint64
+	stride := wordsPerRow(width)
+	row := make([]bool, width)
+	for y := 0; y < height; y++ {
+		if err := readPlainRow(reader, row); err != nil {
+			return nil, err
 		}
+		bits = slices.Grow(bits, stride)[:len(bits)+stride]
+		packBools(bits[y*stride:(y+1)*stride], row)
+	}
+	return bits, nil
+}
 
-		// Initialiser une ligne pour stocker les valeurs des pixels
-		row := make([]bool, width)
-		for i, char := range strings.Fields(line) {
-			// Convertir chaque caractère en un booléen et le stocker dans la ligne
-			row[i] = char == "1"
+// readPlainRow lit une ligne de pixels d'une image P1.
+func readPlainRow(reader *header.Reader, row []bool) error {
+	for x := range row {
+		bit, err := reader.ReadBit()
+		if err != nil {
+			return err
 		}
-		// Ajouter la ligne à la matrice
-		data = append(data, row)
+		row[x] = bit
 	}
+	return nil
+}
 
-	// Vérifier s'il y a des erreurs lors de la lecture du fichier
-	if err := scanner.Err(); err != nil {
-		return nil, err
+// readRaw lit les pixels d'une image P4, où chaque octet contient huit pixels
+// et chaque ligne est complétée jusqu'à la limite d'un octet, et les renvoie compactés.
+func readRaw(reader *header.Reader, width, height int) ([]uint64, error) {
+	var bits []uint64
+	stride := wordsPerRow(width)
+	raw := make([]byte, (width+7)/8)
+	for y := 0; y < height; y++ {
+		if err := reader.ReadFull(raw, "pixels"); err != nil {
+			return nil, err
+		}
+		bits = slices.Grow(bits, stride)[:len(bits)+stride]
+		packBytes(bits[y*stride:(y+1)*stride], raw, width)
 	}
+	return bits, nil
+}
 
-	// Créer et renvoyer une nouvelle instance de la structure PBM avec les données lues
-	return &PBM{
-		data:        data,
-		width:       width,
-		height:      height,
-		magicNumber: magicNumber,
-	}, nil
+// readRawRow lit une ligne de pixels d'une image P4, en utilisant raw comme tampon.
+func readRawRow(reader *header.Reader, raw []byte, row []bool) error {
+	if err := reader.ReadFull(raw, "pixels"); err != nil {
+		return err
+	}
+	for x := range row {
+		row[x] = raw[x/8]&(0x80>>uint(x%8)) != 0
+	}
+	return nil
 }
 
 // Size renvoie la largeur et la hauteur de l'image.
@@ -92,72 +144,174 @@ func (pbm *PBM) Size() (int, int) {
 
 // At renvoie la valeur du pixel en (x, y).
 func (pbm *PBM) At(x, y int) bool {
-	if len(pbm.data) == 0 || x < 0 || y < 0 || x >= pbm.width || y >= pbm.height {
+	if len(pbm.bits) == 0 || x < 0 || y < 0 || x >= pbm.width || y >= pbm.height {
 		// Les coordonnées sont hors de la plage valide ou le tableau est vide.
 		// Vous pouvez renvoyer une valeur par défaut ou gérer l'erreur de la manière qui vous convient.
 		return false
 	}
 
-	return pbm.data[y][x]
+	return pbm.bits[y*pbm.stride+x/64]&bitMask(x) != 0
 }
 
 // Set définit la valeur du pixel à (x, y).
 func (pbm *PBM) Set(x, y int, value bool) {
-	pbm.data[y][x] = value
+	if x < 0 || x >= pbm.width {
+		panic(fmt.Sprintf("pbm: abscisse %d hors de l'image", x))
+	}
+	word := &pbm.row(y)[x/64]
+	if value {
+		*word |= bitMask(x)
+	} else {
+		*word &^= bitMask(x)
+	}
+}
+
+// row renvoie les mots de la ligne y de l'image, qui partagent le tampon des pixels.
+func (pbm *PBM) row(y int) []uint64 {
+	return pbm.bits[y*pbm.stride : (y+1)*pbm.stride]
+}
+
+// Pix renvoie le tampon des pixels compactés, sans copie : le pixel (x, y) est le bit
+// 63 - x%64 du mot d'indice y*Stride() + x/64. Les modifications du tampon sont visibles
+// dans l'image ; les bits de remplissage en fin de ligne doivent rester à 0.
+func (pbm *PBM) Pix() []uint64 {
+	return pbm.bits
+}
+
+// Stride renvoie l'écart, en nombre de mots, entre deux lignes consécutives de Pix.
+func (pbm *PBM) Stride() int {
+	return pbm.stride
+}
+
+// Validate vérifie la cohérence de l'image : nombre magique P1 ou P4, dimensions positives,
+// tampon de pixels de la taille annoncée et bits de remplissage à 0.
+func (pbm *PBM) Validate() error {
+	if pbm.magicNumber != "P1" && pbm.magicNumber != "P4" {
+		return fmt.Errorf("nombre magique non valide : %q", pbm.magicNumber)
+	}
+	if pbm.width < 0 || pbm.height < 0 {
+		return fmt.Errorf("dimensions non valides : %d × %d", pbm.width, pbm.height)
+	}
+	if pbm.stride != wordsPerRow(pbm.width) || len(pbm.bits) != pbm.stride*pbm.height {
+		return fmt.Errorf("%d mots de pixels avec un pas de %d pour une image de %d × %d", len(pbm.bits), pbm.stride, pbm.width, pbm.height)
+	}
+	if pbm.stride == 0 {
+		return nil
+	}
+	mask := lastWordMask(pbm.width)
+	for y := 0; y < pbm.height; y++ {
+		if pbm.row(y)[pbm.stride-1]&^mask != 0 {
+			return fmt.Errorf("la ligne %d a des bits de remplissage non nuls", y)
+		}
+	}
+	return nil
 }
 
 // Save enregistre l'image PBM dans un fichier et renvoie une erreur en cas de problème.
+// Une erreur est renvoyée, sans créer le fichier, si Validate échoue.
+// Les pixels sont écrits en binaire compact si le nombre magique est P4, en texte sinon.
 func (pbm *PBM) Save(filename string) error {
+	if err := pbm.Validate(); err != nil {
+		return err
+	}
+
 	file, err := os.Create(filename)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	// Écrire le nombre magique et les dimensions
-	fmt.Fprintf(file, "%s\n%d %d\n", pbm.magicNumber, pbm.width, pbm.height)
-
-	// Écrire les pixels
-	for _, row := range pbm.data {
-		for _, pixel := range row {
-			if pixel {
-				fmt.Fprint(file, "1 ")
-			} else {
-				fmt.Fprint(file, "0 ")
-			}
-		}
-		fmt.Fprintln(file)
+	if err := pbm.encode(file, header.DefaultWriteOptions); err != nil {
+		return err
 	}
+	return file.Close()
+}
 
-	return nil
+// Encode écrit l'image PBM dans w au format indiqué par son nombre magique.
+// Une erreur est renvoyée, sans rien écrire, si Validate échoue.
+// Les options header.DefaultWriteOptions sont appliquées.
+func (pbm *PBM) Encode(w io.Writer) error {
+	return pbm.EncodeWithOptions(w, header.DefaultWriteOptions)
 }
 
-// Inverser inverse les couleurs de l'image PBM.
-func (pbm *PBM) Invert() {
-	for i := 0; i < pbm.height; i++ {
-		for j := 0; j < pbm.width; j++ {
-			pbm.data[i][j] = !pbm.data[i][j]
-		}
+// EncodeWithOptions écrit l'image PBM dans w, en mettant en forme les pixels selon
+// options si le nombre magique est P1.
+func (pbm *PBM) EncodeWithOptions(w io.Writer, options header.WriteOptions) error {
+	if err := options.Check(); err != nil {
+		return err
+	}
+	if err := pbm.Validate(); err != nil {
+		return err
 	}
+	return pbm.encode(w, options)
 }
 
-// Flip retourne l'image PBM horizontalement.
-func (pbm *PBM) Flip() {
-	for i := 0; i < pbm.height; i++ {
-		for j := 0; j < pbm.width/2; j++ {
-			pbm.data[i][j], pbm.data[i][pbm.width-j-1] = pbm.data[i][pbm.width-j-1], pbm.data[i][j]
+// encode écrit l'en-tête et les pixels de l'image PBM dans w.
+func (pbm *PBM) encode(w io.Writer, options header.WriteOptions) error {
+	writer := header.NewTextWriter(w, options)
+	writeHeader(writer, pbm.magicNumber, pbm.comments, pbm.width, pbm.height)
+	raw := make([]byte, (pbm.width+7)/8)
+	row := make([]bool, pbm.width)
+	for y := 0; y < pbm.height; y++ {
+		if pbm.magicNumber == "P4" {
+			unpackBytes(raw, pbm.row(y))
+			writer.Write(raw)
+		} else {
+			unpackBools(row, pbm.row(y))
+			writeRow(writer, row, pbm.magicNumber)
 		}
 	}
+	return writer.Flush()
+}
+
+// writeHeader écrit le nombre magique et les dimensions d'une image PBM.
+// Les commentaires sont écrits juste après le nombre magique.
+func writeHeader(writer *header.TextWriter, magicNumber string, comments []string, width, height int) {
+	fmt.Fprintf(writer, "%s\n", magicNumber)
+	header.WriteComments(writer, comments)
+	fmt.Fprintf(writer, "%d %d\n", width, height)
 }
 
-// Flop floppe l'image PBM verticalement.
-func (pbm *PBM) Flop() {
-	for i := 0; i < pbm.height/2; i++ {
-		pbm.data[i], pbm.data[pbm.height-i-1] = pbm.data[pbm.height-i-1], pbm.data[i]
+// writeRow écrit une ligne de pixels, en binaire compact si le nombre magique est P4, en texte sinon.
+// Les erreurs d'écriture sont conservées par writer et renvoyées par Flush.
+func writeRow(writer *header.TextWriter, row []bool, magicNumber string) {
+	if magicNumber == "P4" {
+		raw := make([]byte, (len(row)+7)/8)
+		for x, pixel := range row {
+			if pixel {
+				raw[x/8] |= 0x80 >> uint(x%8)
+			}
+		}
+		writer.Write(raw)
+		return
+	}
+	for _, pixel := range row {
+		if pixel {
+			writer.WriteValue("1")
+		} else {
+			writer.WriteValue("0")
+		}
 	}
+	writer.EndLine()
+}
+
+// MagicNumber renvoie le nombre magique de l'image PBM.
+func (pbm *PBM) MagicNumber() string {
+	return pbm.magicNumber
 }
 
 // SetMagicNumber définit le nombre magique de l'image PBM.
 func (pbm *PBM) SetMagicNumber(magicNumber string) {
 	pbm.magicNumber = magicNumber
 }
+
+// Comments renvoie une copie des commentaires de l'en-tête, sans le # initial.
+func (pbm *PBM) Comments() []string {
+	return slices.Clone(pbm.comments)
+}
+
+// SetComments définit les commentaires écrits dans l'en-tête par Save et Encode.
+// Un commentaire contenant des sauts de ligne est écrit sur plusieurs lignes.
+func (pbm *PBM) SetComments(comments []string) {
+	pbm.comments = slices.Clone(comments)
+}
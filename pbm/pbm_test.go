@@ -0,0 +1,94 @@
+package pbm
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for _, magicNumber := range []string{"P1", "P4"} {
+		for _, width := range []int{1, 7, 9, 13, 100} {
+			pbm, ref := randomImage(rng, width, 4)
+			pbm.SetMagicNumber(magicNumber)
+			var buf bytes.Buffer
+			if err := pbm.Encode(&buf); err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := Decode(&buf)
+			if err != nil {
+				t.Fatalf("%s, largeur %d : %v", magicNumber, width, err)
+			}
+			if decoded.MagicNumber() != magicNumber {
+				t.Errorf("nombre magique %s, %s attendu", decoded.MagicNumber(), magicNumber)
+			}
+			check(t, magicNumber, decoded, ref)
+		}
+	}
+}
+
+func TestEncodeRaw(t *testing.T) {
+	// 10 pixels par ligne : deux octets, dont les six derniers bits sont du remplissage
+	pbm := NewPBM(10, 2)
+	pbm.SetMagicNumber("P4")
+	for _, x := range []int{0, 7, 8, 9} {
+		pbm.Set(x, 0, true)
+	}
+	pbm.Set(1, 1, true)
+	var buf bytes.Buffer
+	if err := pbm.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := "P4\n10 2\n\x81\xc0\x40\x00"; buf.String() != want {
+		t.Errorf("%q, %q attendu", buf.String(), want)
+	}
+}
+
+func TestDecodeRawWhitespaceBytes(t *testing.T) {
+	// Un seul caractère d'espacement sépare les dimensions des pixels : les octets
+	// suivants sont des pixels, même s'ils ressemblent à des espaces ou à un commentaire
+	pbm, err := Decode(bytes.NewReader([]byte("P4 8 3\n\n #")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y, row := range []byte{'\n', ' ', '#'} {
+		for x := 0; x < 8; x++ {
+			if want := row&(0x80>>x) != 0; pbm.At(x, y) != want {
+				t.Errorf("pixel (%d, %d) vaut %v, %v attendu", x, y, pbm.At(x, y), want)
+			}
+		}
+	}
+}
+
+func TestDecodeRawPadding(t *testing.T) {
+	// Les bits de remplissage du fichier sont ignorés, même s'ils valent 1
+	pbm, err := Decode(bytes.NewReader([]byte("P4 3 2\n\xbf\xff")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(t, "Decode", pbm, reference{{true, false, true}, {true, true, true}})
+}
+
+func TestRefuseInvalid(t *testing.T) {
+	badMagic := NewPBM(3, 1)
+	badMagic.SetMagicNumber("P2")
+	// Un bit de remplissage à 1 après les trois pixels de la ligne
+	padding := NewPBM(3, 1)
+	padding.Pix()[0] = 1
+	for name, pbm := range map[string]*PBM{"nombre magique": badMagic, "remplissage": padding} {
+		filename := filepath.Join(t.TempDir(), "image.pbm")
+		if err := pbm.Save(filename); err == nil {
+			t.Errorf("%s : Save accepte une image non valide", name)
+		}
+		if _, err := os.Stat(filename); !os.IsNotExist(err) {
+			t.Errorf("%s : Save a créé le fichier (%v)", name, err)
+		}
+		var buf bytes.Buffer
+		if err := pbm.Encode(&buf); err == nil || buf.Len() > 0 {
+			t.Errorf("%s : Encode écrit %d octets, erreur %v", name, buf.Len(), err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package pbm
+
+import (
+	"Netpbm/header"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// RowReader lit une image PBM ligne par ligne, sans jamais conserver plus d'une ligne
+// en mémoire, ce qui permet de traiter des images de taille arbitraire.
+type RowReader struct {
+	reader        *header.Reader
+	width, height int
+	magicNumber   string
+	y             int
+	raw           []byte
+}
+
+// NewRowReader lit l'en-tête de l'image PBM contenue dans r et renvoie un RowReader
+// positionné sur la première ligne. Les limites header.DefaultRowLimits, qui ne bornent
+// que la taille d'une ligne, sont appliquées.
+func NewRowReader(r io.Reader) (*RowReader, error) {
+	return NewRowReaderWithLimits(r, header.DefaultRowLimits)
+}
+
+// NewRowReaderWithLimits lit l'en-tête de l'image PBM contenue dans r en appliquant limits.
+func NewRowReaderWithLimits(r io.Reader, limits header.Limits) (*RowReader, error) {
+	reader := header.NewReader(r)
+	pbm, err := readHeader(reader, limits)
+	if err != nil {
+		return nil, err
+	}
+	rr := &RowReader{
+		reader:      reader,
+		width:       pbm.width,
+		height:      pbm.height,
+		magicNumber: pbm.magicNumber,
+	}
+	if rr.magicNumber == "P4" {
+		rr.raw = make([]byte, (rr.width+7)/8)
+	}
+	return rr, nil
+}
+
+// Size renvoie la largeur et la hauteur de l'image.
+func (rr *RowReader) Size() (int, int) {
+	return rr.width, rr.height
+}
+
+// MagicNumber renvoie le nombre magique de l'image.
+func (rr *RowReader) MagicNumber() string {
+	return rr.magicNumber
+}
+
+// ReadRow lit la ligne suivante dans row, qui doit contenir exactement une ligne de pixels.
+// io.EOF est renvoyé une fois toutes les lignes lues.
+func (rr *RowReader) ReadRow(row []bool) error {
+	if rr.y >= rr.height {
+		return io.EOF
+	}
+	if len(row) != rr.width {
+		return fmt.Errorf("ligne de %d pixels, %d attendus", len(row), rr.width)
+	}
+	var err error
+	if rr.magicNumber == "P1" {
+		err = readPlainRow(rr.reader, row)
+	} else {
+		err = readRawRow(rr.reader, rr.raw, row)
+	}
+	if err != nil {
+		return err
+	}
+	rr.y++
+	return nil
+}
+
+// RowWriter écrit une image PBM ligne par ligne.
+type RowWriter struct {
+	writer        *header.TextWriter
+	width, height int
+	magicNumber   string
+	y             int
+}
+
+// NewRowWriter écrit l'en-tête d'une image PBM dans w et renvoie un RowWriter
+// qui attend ensuite height lignes de width pixels.
+// Les options header.DefaultWriteOptions sont appliquées.
+func NewRowWriter(w io.Writer, width, height int, magicNumber string) (*RowWriter, error) {
+	return NewRowWriterWithOptions(w, width, height, magicNumber, header.DefaultWriteOptions)
+}
+
+// NewRowWriterWithOptions fonctionne comme NewRowWriter, en mettant en forme les pixels
+// selon options si le nombre magique est P1.
+func NewRowWriterWithOptions(w io.Writer, width, height int, magicNumber string, options header.WriteOptions) (*RowWriter, error) {
+	if magicNumber != "P1" && magicNumber != "P4" {
+		return nil, fmt.Errorf("nombre magique non valide : %q", magicNumber)
+	}
+	if width < 0 || height < 0 {
+		return nil, fmt.Errorf("dimensions non valides : %d × %d", width, height)
+	}
+	if err := options.Check(); err != nil {
+		return nil, err
+	}
+	rw := &RowWriter{
+		writer:      header.NewTextWriter(w, options),
+		width:       width,
+		height:      height,
+		magicNumber: magicNumber,
+	}
+	writeHeader(rw.writer, magicNumber, nil, width, height)
+	return rw, nil
+}
+
+// WriteRow écrit la ligne suivante de l'image.
+func (rw *RowWriter) WriteRow(row []bool) error {
+	if rw.y >= rw.height {
+		return errors.New("toutes les lignes de l'image ont déjà été écrites")
+	}
+	if len(row) != rw.width {
+		return fmt.Errorf("ligne de %d pixels, %d attendus", len(row), rw.width)
+	}
+	writeRow(rw.writer, row, rw.magicNumber)
+	rw.y++
+	return nil
+}
+
+// Close vide le tampon d'écriture et vérifie que toutes les lignes ont été écrites.
+// Le io.Writer sous-jacent n'est pas fermé.
+func (rw *RowWriter) Close() error {
+	if err := rw.writer.Flush(); err != nil {
+		return err
+	}
+	if rw.y < rw.height {
+		return fmt.Errorf("%d lignes écrites sur %d", rw.y, rw.height)
+	}
+	return nil
+}
+
+// InvertRow inverse les couleurs d'une ligne de pixels.
+func InvertRow(row []bool) {
+	for x := range row {
+		row[x] = !row[x]
+	}
+}
+
+// FlipRow retourne une ligne de pixels horizontalement.
+func FlipRow(row []bool) {
+	for x := 0; x < len(row)/2; x++ {
+		row[x], row[len(row)-x-1] = row[len(row)-x-1], row[x]
+	}
+}
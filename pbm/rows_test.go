@@ -0,0 +1,111 @@
+package pbm
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestRowRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	for _, magicNumber := range []string{"P1", "P4"} {
+		for _, width := range widths {
+			_, ref := randomImage(rng, width, 3)
+			var buf bytes.Buffer
+			rw, err := NewRowWriter(&buf, width, 3, magicNumber)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, row := range ref {
+				if err := rw.WriteRow(row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := rw.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			rr, err := NewRowReader(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if w, h := rr.Size(); w != width || h != 3 || rr.MagicNumber() != magicNumber {
+				t.Fatalf("%s %d × %d, %s %d × 3 attendu", rr.MagicNumber(), w, h, magicNumber, width)
+			}
+			row := make([]bool, width)
+			for y := range ref {
+				if err := rr.ReadRow(row); err != nil {
+					t.Fatal(err)
+				}
+				if !slices.Equal(row, ref[y]) {
+					t.Errorf("%s, largeur %d : ligne %d vaut %v, %v attendu", magicNumber, width, y, row, ref[y])
+				}
+			}
+			if err := rr.ReadRow(row); err != io.EOF {
+				t.Errorf("après la dernière ligne : %v, io.EOF attendu", err)
+			}
+
+			// Le résultat se relit aussi d'un bloc
+			decoded, err := Decode(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			check(t, magicNumber, decoded, ref)
+		}
+	}
+}
+
+func TestRowErrors(t *testing.T) {
+	var buf bytes.Buffer
+	rw, err := NewRowWriter(&buf, 3, 2, "P4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.WriteRow(make([]bool, 2)); err == nil {
+		t.Error("WriteRow accepte une ligne de 2 pixels pour une largeur de 3")
+	}
+	if err := rw.WriteRow(make([]bool, 3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.Close(); err == nil {
+		t.Error("Close accepte une image à laquelle il manque une ligne")
+	}
+
+	rr, err := NewRowReader(bytes.NewReader([]byte("P1 3 1 0 1 0")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rr.ReadRow(make([]bool, 4)); err == nil {
+		t.Error("ReadRow accepte une ligne de 4 pixels pour une largeur de 3")
+	}
+}
+
+func TestRowOperations(t *testing.T) {
+	for _, width := range widths {
+		// Trois images identiques, tirées avec la même graine
+		image := func() (*PBM, reference) { return randomImage(rand.New(rand.NewSource(6)), width, 3) }
+		_, ref := image()
+		inverted, _ := image()
+		flipped, _ := image()
+		inverted.Invert()
+		flipped.Flip()
+		for y, row := range ref {
+			got := slices.Clone(row)
+			InvertRow(got)
+			for x := range got {
+				if got[x] != inverted.At(x, y) {
+					t.Fatalf("InvertRow, largeur %d : pixel (%d, %d) vaut %v, Invert donne %v", width, x, y, got[x], inverted.At(x, y))
+				}
+			}
+			got = slices.Clone(row)
+			FlipRow(got)
+			for x := range got {
+				if got[x] != flipped.At(x, y) {
+					t.Fatalf("FlipRow, largeur %d : pixel (%d, %d) vaut %v, Flip donne %v", width, x, y, got[x], flipped.At(x, y))
+				}
+			}
+		}
+	}
+}
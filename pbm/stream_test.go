@@ -0,0 +1,59 @@
+package pbm
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestReaderNext(t *testing.T) {
+	// Les pixels d'une image P1 s'arrêtent à la fin de sa dernière ligne : les données
+	// suivantes appartiennent à l'image d'après, et non à des lignes supplémentaires
+	input := "P1 2 1\n1 0\n" +
+		"P1\n3 1\n011P4 8 2\n\n#" +
+		"\n# fin du flux\n"
+	want := []reference{
+		{{true, false}},
+		{{false, true, true}},
+		{{false, false, false, false, true, false, true, false}, {false, false, true, false, false, false, true, true}},
+	}
+	r := NewReader(strings.NewReader(input))
+	for i, ref := range want {
+		pbm, err := r.Next()
+		if err != nil {
+			t.Fatalf("image %d : %v", i, err)
+		}
+		check(t, "Next", pbm, ref)
+	}
+	if pbm, err := r.Next(); err != io.EOF {
+		t.Fatalf("après la dernière image : %v, %v, io.EOF attendu", pbm, err)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	var refs []reference
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for i, magicNumber := range []string{"P4", "P1", "P4"} {
+		pbm, ref := randomImage(rng, 5+i*4, 3)
+		pbm.SetMagicNumber(magicNumber)
+		if err := w.Write(pbm); err != nil {
+			t.Fatal(err)
+		}
+		refs = append(refs, ref)
+	}
+	r := NewReader(&buf)
+	for _, ref := range refs {
+		pbm, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, "Writer", pbm, ref)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("%v, io.EOF attendu", err)
+	}
+}
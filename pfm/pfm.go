@@ -0,0 +1,303 @@
+package pfm
+
+import (
+	"Netpbm/header"
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+)
+
+// PFM représente une image PFM, dont les échantillons sont des flottants sur 32 bits :
+// trois par pixel pour le format couleur PF, un seul pour le format en niveaux de gris Pf.
+// Les lignes sont stockées de haut en bas dans un tampon contigu où l'échantillon c
+// du pixel (x, y) se trouve à l'indice y*stride + x*Channels() + c ; l'ordre du fichier,
+// de bas en haut, n'intervient qu'à la lecture et à l'écriture.
+type PFM struct {
+	pix           []float32
+	stride        int
+	width, height int
+	magicNumber   string
+	scale         float64
+	byteOrder     binary.ByteOrder
+}
+
+// NewPFM crée une nouvelle image PFM en couleur (PF), noire, avec la largeur et la hauteur
+// spécifiées, un facteur d'échelle de 1 et des échantillons en petit-boutiste.
+func NewPFM(width, height int) *PFM {
+	return newPFM(width, height, "PF")
+}
+
+// NewGrayPFM crée une nouvelle image PFM en niveaux de gris (Pf), noire, avec la largeur
+// et la hauteur spécifiées, un facteur d'échelle de 1 et des échantillons en petit-boutiste.
+func NewGrayPFM(width, height int) *PFM {
+	return newPFM(width, height, "Pf")
+}
+
+// newPFM crée une image PFM noire dont le nombre magique est magicNumber.
+func newPFM(width, height int, magicNumber string) *PFM {
+	stride := width * channels(magicNumber)
+	return &PFM{
+		pix:         make([]float32, stride*height),
+		stride:      stride,
+		width:       width,
+		height:      height,
+		magicNumber: magicNumber,
+		scale:       1,
+		byteOrder:   binary.LittleEndian,
+	}
+}
+
+// channels renvoie le nombre d'échantillons par pixel associé au nombre magique.
+func channels(magicNumber string) int {
+	if magicNumber == "PF" {
+		return 3
+	}
+	return 1
+}
+
+// ReadPFM lit une image PFM à partir d'un fichier et renvoie une structure qui représente l'image.
+func ReadPFM(filename string) (*PFM, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Decode(file)
+}
+
+// Decode lit une image PFM depuis r et renvoie une structure qui représente l'image.
+// Les limites header.DefaultLimits sont appliquées.
+func Decode(r io.Reader) (*PFM, error) {
+	return DecodeWithLimits(r, header.DefaultLimits)
+}
+
+// DecodeWithLimits lit une image PFM depuis r en refusant, avant toute allocation,
+// les images qui dépassent limits. Les pixels sont alloués au fur et à mesure de la
+// lecture, de sorte qu'un fichier tronqué échoue sans réserver la taille annoncée.
+func DecodeWithLimits(r io.Reader, limits header.Limits) (*PFM, error) {
+	reader := header.NewReader(r)
+
+	pfm, err := readHeader(reader, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	pfm.pix, err = readRaw(reader, pfm.stride, pfm.height, pfm.byteOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	// Les lignes du fichier sont écrites de bas en haut
+	pfm.Flop()
+	return pfm, nil
+}
+
+// readHeader lit l'en-tête d'une image PFM (nombre magique, dimensions et facteur d'échelle)
+// et renvoie une image sans pixels si elle respecte limits. Le signe du facteur d'échelle
+// détermine l'ordre des octets : petit-boutiste s'il est négatif, gros-boutiste sinon.
+func readHeader(reader *header.Reader, limits header.Limits) (*PFM, error) {
+	h, err := header.Read(reader, "PF", "Pf")
+	if err != nil {
+		return nil, err
+	}
+	if err := limits.Check(reader, h); err != nil {
+		return nil, err
+	}
+	pfm := &PFM{
+		stride:      h.Width * h.Depth,
+		width:       h.Width,
+		height:      h.Height,
+		magicNumber: h.MagicNumber,
+		scale:       math.Abs(h.Scale),
+		byteOrder:   binary.BigEndian,
+	}
+	if h.Scale < 0 {
+		pfm.byteOrder = binary.LittleEndian
+	}
+	return pfm, nil
+}
+
+// readRaw lit les échantillons d'une image PFM et les renvoie dans l'ordre du fichier.
+func readRaw(reader *header.Reader, stride, height int, byteOrder binary.ByteOrder) ([]float32, error) {
+	var pix []float32
+	raw := make([]byte, 4*stride)
+	for y := 0; y < height; y++ {
+		pix = slices.Grow(pix, stride)[:len(pix)+stride]
+		if err := reader.ReadFull(raw, "pixels"); err != nil {
+			return nil, err
+		}
+		row := pix[y*stride : (y+1)*stride]
+		for i := range row {
+			row[i] = math.Float32frombits(byteOrder.Uint32(raw[4*i:]))
+		}
+	}
+	return pix, nil
+}
+
+// Size renvoie la largeur et la hauteur de l'image.
+func (pfm *PFM) Size() (int, int) {
+	return pfm.width, pfm.height
+}
+
+// Channels renvoie le nombre d'échantillons par pixel : 3 pour PF, 1 pour Pf.
+func (pfm *PFM) Channels() int {
+	return channels(pfm.magicNumber)
+}
+
+// MagicNumber renvoie le nombre magique de l'image, PF ou Pf.
+func (pfm *PFM) MagicNumber() string {
+	return pfm.magicNumber
+}
+
+// Scale renvoie la valeur absolue du facteur d'échelle de l'en-tête.
+func (pfm *PFM) Scale() float64 {
+	return pfm.scale
+}
+
+// SetScale définit le facteur d'échelle, qui doit être strictement positif.
+// L'ordre des octets, codé par le signe dans le fichier, se règle avec SetByteOrder.
+func (pfm *PFM) SetScale(scale float64) {
+	pfm.scale = scale
+}
+
+// ByteOrder renvoie l'ordre des octets des échantillons dans le fichier.
+func (pfm *PFM) ByteOrder() binary.ByteOrder {
+	return pfm.byteOrder
+}
+
+// SetByteOrder définit l'ordre des octets utilisé par Save et Encode :
+// binary.LittleEndian ou binary.BigEndian.
+func (pfm *PFM) SetByteOrder(byteOrder binary.ByteOrder) {
+	pfm.byteOrder = byteOrder
+}
+
+// At renvoie les échantillons du pixel en (x, y), sans copie : les modifications
+// de la tranche renvoyée sont visibles dans l'image.
+func (pfm *PFM) At(x, y int) []float32 {
+	n := pfm.Channels()
+	return pfm.row(y)[x*n : (x+1)*n]
+}
+
+// Set définit les échantillons du pixel en (x, y). values doit contenir Channels() échantillons.
+func (pfm *PFM) Set(x, y int, values []float32) {
+	if len(values) != pfm.Channels() {
+		panic(fmt.Sprintf("pfm: %d échantillons pour %d canaux", len(values), pfm.Channels()))
+	}
+	copy(pfm.At(x, y), values)
+}
+
+// row renvoie la ligne y de l'image, comptée depuis le haut, qui partage le tampon des pixels.
+func (pfm *PFM) row(y int) []float32 {
+	return pfm.pix[y*pfm.stride : y*pfm.stride+pfm.width*pfm.Channels()]
+}
+
+// Pix renvoie le tampon contigu des échantillons, sans copie : l'échantillon c du pixel
+// (x, y), compté depuis le haut, se trouve à l'indice y*Stride() + x*Channels() + c.
+func (pfm *PFM) Pix() []float32 {
+	return pfm.pix
+}
+
+// Stride renvoie l'écart, en nombre d'échantillons, entre deux lignes consécutives de Pix.
+func (pfm *PFM) Stride() int {
+	return pfm.stride
+}
+
+// Validate vérifie la cohérence de l'image : nombre magique PF ou Pf, dimensions positives,
+// tampon assez grand pour le pas annoncé, facteur d'échelle fini et strictement positif
+// et ordre des octets petit- ou gros-boutiste.
+func (pfm *PFM) Validate() error {
+	if pfm.magicNumber != "PF" && pfm.magicNumber != "Pf" {
+		return fmt.Errorf("nombre magique non valide : %q", pfm.magicNumber)
+	}
+	if pfm.width < 0 || pfm.height < 0 {
+		return fmt.Errorf("dimensions non valides : %d × %d", pfm.width, pfm.height)
+	}
+	n := pfm.Channels()
+	if pfm.height > 0 && (pfm.stride < pfm.width*n || len(pfm.pix) < (pfm.height-1)*pfm.stride+pfm.width*n) {
+		return fmt.Errorf("%d échantillons avec un pas de %d pour une image de %d × %d × %d", len(pfm.pix), pfm.stride, pfm.width, pfm.height, n)
+	}
+	if !(pfm.scale > 0) || math.IsInf(pfm.scale, 0) {
+		return fmt.Errorf("facteur d'échelle non valide : %v", pfm.scale)
+	}
+	if pfm.byteOrder != binary.LittleEndian && pfm.byteOrder != binary.BigEndian {
+		return fmt.Errorf("ordre des octets non valide : %v", pfm.byteOrder)
+	}
+	return nil
+}
+
+// Save enregistre l'image PFM dans un fichier et renvoie une erreur en cas de problème.
+// Une erreur est renvoyée, sans créer le fichier, si Validate échoue.
+func (pfm *PFM) Save(filename string) error {
+	if err := pfm.Validate(); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := pfm.encode(file); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Encode écrit l'image PFM dans w, les lignes de bas en haut.
+// Une erreur est renvoyée, sans rien écrire, si Validate échoue.
+func (pfm *PFM) Encode(w io.Writer) error {
+	if err := pfm.Validate(); err != nil {
+		return err
+	}
+	return pfm.encode(w)
+}
+
+// encode écrit l'en-tête et les échantillons de l'image PFM dans w.
+func (pfm *PFM) encode(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+	scale := pfm.scale
+	if pfm.byteOrder == binary.LittleEndian {
+		scale = -scale
+	}
+	fmt.Fprintf(writer, "%s\n%d %d\n%s\n", pfm.magicNumber, pfm.width, pfm.height, strconv.FormatFloat(scale, 'f', -1, 64))
+	raw := make([]byte, 4*pfm.width*pfm.Channels())
+	for y := pfm.height - 1; y >= 0; y-- {
+		for i, val := range pfm.row(y) {
+			pfm.byteOrder.PutUint32(raw[4*i:], math.Float32bits(val))
+		}
+		writer.Write(raw)
+	}
+	return writer.Flush()
+}
+
+// Flip retourne l'image PFM horizontalement.
+func (pfm *PFM) Flip() {
+	n := pfm.Channels()
+	for y := 0; y < pfm.height; y++ {
+		row := pfm.row(y)
+		for x := 0; x < pfm.width/2; x++ {
+			left := row[x*n : (x+1)*n]
+			right := row[(pfm.width-x-1)*n : (pfm.width-x)*n]
+			for c := range left {
+				left[c], right[c] = right[c], left[c]
+			}
+		}
+	}
+}
+
+// Flop retourne l'image PFM verticalement.
+func (pfm *PFM) Flop() {
+	for y := 0; y < pfm.height/2; y++ {
+		top, bottom := pfm.row(y), pfm.row(pfm.height-y-1)
+		for i := range top {
+			top[i], bottom[i] = bottom[i], top[i]
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package pfm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestDecodeRowOrder(t *testing.T) {
+	// Le fichier stocke les lignes de bas en haut : 1 est la ligne du bas, 2 celle du haut
+	input := "Pf\n1 2\n-1.0\n\x00\x00\x80\x3f\x00\x00\x00\x40"
+	pfm, err := Decode(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if top, bottom := pfm.At(0, 0)[0], pfm.At(0, 1)[0]; top != 2 || bottom != 1 {
+		t.Errorf("haut %v, bas %v ; 2 et 1 attendus", top, bottom)
+	}
+	if pfm.ByteOrder() != binary.LittleEndian || pfm.Scale() != 1 {
+		t.Errorf("ordre %v, échelle %v ; petit-boutiste et 1 attendus", pfm.ByteOrder(), pfm.Scale())
+	}
+}
+
+func TestEncodeByteOrder(t *testing.T) {
+	tests := []struct {
+		byteOrder binary.ByteOrder
+		scale     float64
+		want      string
+	}{
+		// Une échelle négative annonce des échantillons petit-boutistes, positive gros-boutistes
+		{binary.LittleEndian, 1, "Pf\n1 2\n-1\n\x00\x00\x00\x40\x00\x00\x80\x3f"},
+		{binary.BigEndian, 1, "Pf\n1 2\n1\n\x40\x00\x00\x00\x3f\x80\x00\x00"},
+		{binary.BigEndian, 0.5, "Pf\n1 2\n0.5\n\x40\x00\x00\x00\x3f\x80\x00\x00"},
+	}
+	for _, test := range tests {
+		pfm := NewGrayPFM(1, 2)
+		pfm.Set(0, 0, []float32{1})
+		pfm.Set(0, 1, []float32{2})
+		pfm.SetByteOrder(test.byteOrder)
+		pfm.SetScale(test.scale)
+		var buf bytes.Buffer
+		if err := pfm.Encode(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != test.want {
+			t.Errorf("%v, échelle %v : %q, %q attendu", test.byteOrder, test.scale, buf.String(), test.want)
+		}
+		decoded, err := Decode(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded.ByteOrder() != test.byteOrder || decoded.Scale() != test.scale || decoded.At(0, 0)[0] != 1 || decoded.At(0, 1)[0] != 2 {
+			t.Errorf("%v, échelle %v : relu %v, échelle %v, pixels %v %v", test.byteOrder, test.scale,
+				decoded.ByteOrder(), decoded.Scale(), decoded.At(0, 0), decoded.At(0, 1))
+		}
+	}
+}
+
+func TestOperators(t *testing.T) {
+	tests := []struct {
+		name     string
+		operator Operator
+		value    float64
+		want     float64
+	}{
+		{"Reinhard", Reinhard, 0, 0},
+		{"Reinhard", Reinhard, 1, 0.5},
+		{"Reinhard", Reinhard, 3, 0.75},
+		{"Reinhard", Reinhard, math.Inf(1), 1},
+		{"Exposure(1)", Exposure(1), 0, 0},
+		{"Exposure(1)", Exposure(1), math.Ln2, 0.5},
+		{"Exposure(2)", Exposure(2), math.Ln2, 0.75},
+		{"Exposure(1)", Exposure(1), math.Inf(1), 1},
+	}
+	for _, test := range tests {
+		if got := test.operator(test.value); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("%s(%v) = %v, %v attendu", test.name, test.value, got, test.want)
+		}
+	}
+}
+
+func TestSample(t *testing.T) {
+	linear := ToneMapping{MaxValue: 255}
+	tests := []struct {
+		mapping ToneMapping
+		value   float64
+		want    uint16
+	}{
+		// Les échantillons négatifs ou NaN deviennent noirs, quel que soit l'opérateur
+		{linear, math.NaN(), 0},
+		{linear, -1, 0},
+		{linear, math.Inf(-1), 0},
+		{ToneMapping{Operator: Reinhard}, math.NaN(), 0},
+		{ToneMapping{Operator: Exposure(1)}, -2, 0},
+		// Au-delà de 1, l'échantillon est borné
+		{linear, 0.5, 128},
+		{linear, 7, 255},
+		{linear, math.Inf(1), 255},
+		{DefaultToneMapping, 0.5, 186},
+		{ToneMapping{Operator: Reinhard, MaxValue: 1000}, 1, 500},
+	}
+	for _, test := range tests {
+		if got := test.mapping.sample(test.value); got != test.want {
+			t.Errorf("sample(%v) avec %+v : %d, %d attendu", test.value, test.mapping, got, test.want)
+		}
+	}
+}
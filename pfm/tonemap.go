@@ -0,0 +1,127 @@
+package pfm
+
+import (
+	"Netpbm/pgm"
+	"Netpbm/ppm"
+	"math"
+)
+
+// Operator ramène un échantillon linéaire positif dans l'intervalle [0, 1].
+// Le résultat est ensuite borné à [0, 1], de sorte qu'un opérateur n'a pas à traiter
+// les valeurs extrêmes.
+type Operator func(value float64) float64
+
+// Clamp conserve les échantillons tels quels : tout ce qui dépasse 1 devient blanc.
+func Clamp(value float64) float64 {
+	return value
+}
+
+// Reinhard compresse les hautes lumières selon l'opérateur global de Reinhard, v / (1 + v).
+func Reinhard(value float64) float64 {
+	if math.IsInf(value, 1) {
+		return 1
+	}
+	return value / (1 + value)
+}
+
+// Exposure renvoie un opérateur qui simule l'exposition d'une pellicule, 1 - exp(-v × exposure).
+// Une exposition plus grande éclaircit l'image ; 1 est un bon point de départ.
+func Exposure(exposure float64) Operator {
+	return func(value float64) float64 {
+		return 1 - math.Exp(-value*exposure)
+	}
+}
+
+// ToneMapping règle la conversion d'une image PFM vers une image PPM ou PGM.
+type ToneMapping struct {
+	// Operator ramène les échantillons dans [0, 1]. Clamp est utilisé s'il est nil.
+	Operator Operator
+	// Gamma est appliqué après Operator : la valeur écrite est v^(1/Gamma).
+	// Zéro désactive la correction, les échantillons restant alors linéaires.
+	Gamma float64
+	// MaxValue est la valeur maximale de l'image produite, 255 si elle est nulle.
+	MaxValue uint16
+}
+
+// DefaultToneMapping borne les échantillons et les encode avec un gamma de 2,2 sur 8 bits.
+var DefaultToneMapping = ToneMapping{
+	Operator: Clamp,
+	Gamma:    2.2,
+	MaxValue: 255,
+}
+
+// sample convertit un échantillon linéaire en entier de la plage [0, MaxValue].
+// Les échantillons négatifs ou NaN deviennent noirs.
+func (t ToneMapping) sample(value float64) uint16 {
+	operator := t.Operator
+	if operator == nil {
+		operator = Clamp
+	}
+	if !(value > 0) {
+		return 0
+	}
+	value = operator(value)
+	if !(value > 0) {
+		return 0
+	}
+	if value > 1 {
+		value = 1
+	}
+	if t.Gamma > 0 {
+		value = math.Pow(value, 1/t.Gamma)
+	}
+	return uint16(math.Round(value * float64(t.maxValue())))
+}
+
+// maxValue renvoie la valeur maximale de l'image produite.
+func (t ToneMapping) maxValue() uint16 {
+	if t.MaxValue == 0 {
+		return 255
+	}
+	return t.MaxValue
+}
+
+// luminance renvoie la luminance linéaire d'un pixel selon les coefficients de la Rec. 709,
+// ou l'échantillon lui-même pour une image en niveaux de gris.
+func luminance(tuple []float32) float64 {
+	if len(tuple) == 1 {
+		return float64(tuple[0])
+	}
+	return 0.2126*float64(tuple[0]) + 0.7152*float64(tuple[1]) + 0.0722*float64(tuple[2])
+}
+
+// PPM convertit l'image PFM en image PPM (P6) selon mapping. Une image en niveaux de gris
+// donne trois composantes égales.
+func (pfm *PFM) PPM(mapping ToneMapping) *ppm.PPM {
+	dst := ppm.NewPPM(pfm.width, pfm.height)
+	dst.SetMaxValue16(mapping.maxValue())
+	dst.SetMagicNumber("P6")
+	pix, stride := dst.Pix(), dst.Stride()
+	for y := 0; y < pfm.height; y++ {
+		for x := 0; x < pfm.width; x++ {
+			tuple := pfm.At(x, y)
+			r := mapping.sample(float64(tuple[0]))
+			g, b := r, r
+			if len(tuple) == 3 {
+				g, b = mapping.sample(float64(tuple[1])), mapping.sample(float64(tuple[2]))
+			}
+			pix[y*stride+x] = ppm.Pixel16{R: r, G: g, B: b}
+		}
+	}
+	return dst
+}
+
+// PGM convertit l'image PFM en image PGM (P5) selon mapping. Une image en couleur est
+// d'abord réduite à sa luminance linéaire, avant l'application de l'opérateur.
+func (pfm *PFM) PGM(mapping ToneMapping) *pgm.PGM {
+	dst := pgm.NewPGM(pfm.width, pfm.height)
+	dst.SetMaxValue16(mapping.maxValue())
+	dst.SetMagicNumber("P5")
+	pix, stride := dst.Pix(), dst.Stride()
+	for y := 0; y < pfm.height; y++ {
+		for x := 0; x < pfm.width; x++ {
+			pix[y*stride+x] = mapping.sample(luminance(pfm.At(x, y)))
+		}
+	}
+	return dst
+}
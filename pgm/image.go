@@ -0,0 +1,111 @@
+package pgm
+
+import (
+	"Netpbm/header"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+)
+
+// Les formats P2 et P5 sont enregistrés auprès du paquet image, de sorte
+// qu'un import de ce paquet suffit pour qu'image.Decode reconnaisse les fichiers PGM.
+func init() {
+	image.RegisterFormat("pgm", "P2", decodeImage, DecodeConfig)
+	image.RegisterFormat("pgm", "P5", decodeImage, DecodeConfig)
+}
+
+// decodeImage lit une image PGM depuis r et renvoie son adaptateur image.Image.
+func decodeImage(r io.Reader) (image.Image, error) {
+	pgm, err := Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	return pgm.Image(), nil
+}
+
+// DecodeConfig renvoie le modèle de couleur et les dimensions d'une image PGM
+// en ne lisant que son en-tête.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	pgm, err := readHeader(header.NewReader(r), header.Limits{})
+	if err != nil {
+		return image.Config{}, err
+	}
+	colorModel := color.GrayModel
+	if pgm.max > 255 {
+		colorModel = color.Gray16Model
+	}
+	return image.Config{ColorModel: colorModel, Width: pgm.width, Height: pgm.height}, nil
+}
+
+// Image adapte une image PGM aux interfaces image.Image et draw.Image.
+// Les pixels sont exposés en color.Gray si la valeur maximale tient sur 8 bits,
+// en color.Gray16 sinon, après mise à l'échelle depuis la plage [0, max].
+type Image struct {
+	pgm *PGM
+}
+
+var _ draw.Image = (*Image)(nil)
+
+// Image renvoie un adaptateur qui partage les pixels de l'image PGM.
+func (pgm *PGM) Image() *Image {
+	return &Image{pgm: pgm}
+}
+
+// PGM renvoie l'image PGM sous-jacente.
+func (img *Image) PGM() *PGM {
+	return img.pgm
+}
+
+// ColorModel renvoie le modèle de couleur de l'image.
+func (img *Image) ColorModel() color.Model {
+	if img.pgm.max <= 255 {
+		return color.GrayModel
+	}
+	return color.Gray16Model
+}
+
+// Bounds renvoie le rectangle occupé par l'image.
+func (img *Image) Bounds() image.Rectangle {
+	return image.Rect(0, 0, img.pgm.width, img.pgm.height)
+}
+
+// At renvoie la couleur du pixel en (x, y).
+func (img *Image) At(x, y int) color.Color {
+	if !(image.Point{X: x, Y: y}.In(img.Bounds())) || img.pgm.max < 1 {
+		return img.ColorModel().Convert(color.Black)
+	}
+	if img.pgm.max <= 255 {
+		return color.Gray{Y: uint8(header.ScaleSample(img.pgm.At16(x, y), img.pgm.max, 255))}
+	}
+	return color.Gray16{Y: header.ScaleSample(img.pgm.At16(x, y), img.pgm.max, 65535)}
+}
+
+// Set définit la couleur du pixel en (x, y). Les coordonnées hors de l'image sont ignorées.
+func (img *Image) Set(x, y int, c color.Color) {
+	if !(image.Point{X: x, Y: y}.In(img.Bounds())) || img.pgm.max < 1 {
+		return
+	}
+	gray := color.Gray16Model.Convert(c).(color.Gray16)
+	img.pgm.Set16(x, y, header.ScaleSample(gray.Y, 65535, img.pgm.max))
+}
+
+// FromImage crée une image PGM (P2) à partir d'une image quelconque en niveaux de gris.
+// La valeur maximale vaut 65535 si l'image source est en 16 bits, 255 sinon.
+func FromImage(src image.Image) *PGM {
+	bounds := src.Bounds()
+	max := 255
+	switch src.ColorModel() {
+	case color.Gray16Model, color.RGBA64Model, color.NRGBA64Model:
+		max = 65535
+	}
+	pgm := NewPGM(bounds.Dx(), bounds.Dy())
+	pgm.max = max
+	for y := 0; y < pgm.height; y++ {
+		for x := 0; x < pgm.width; x++ {
+			gray := color.Gray16Model.Convert(src.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray16)
+			pgm.Set16(x, y, header.ScaleSample(gray.Y, 65535, max))
+		}
+	}
+	return pgm
+}
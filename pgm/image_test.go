@@ -0,0 +1,85 @@
+package pgm
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"testing"
+)
+
+func TestImage(t *testing.T) {
+	tests := []struct {
+		max   uint16
+		model color.Model
+		// samples associe des valeurs de l'image PGM aux couleurs exposées par l'adaptateur
+		samples []uint16
+		colors  []color.Color
+	}{
+		{255, color.GrayModel, []uint16{0, 100, 255}, []color.Color{color.Gray{0}, color.Gray{100}, color.Gray{255}}},
+		{15, color.GrayModel, []uint16{0, 1, 15}, []color.Color{color.Gray{0}, color.Gray{17}, color.Gray{255}}},
+		{4095, color.Gray16Model, []uint16{0, 1, 2048, 4095}, []color.Color{color.Gray16{0}, color.Gray16{16}, color.Gray16{32776}, color.Gray16{65535}}},
+	}
+	for _, test := range tests {
+		pgm := NewPGM(len(test.samples), 2)
+		pgm.SetMaxValue16(test.max)
+		for x, sample := range test.samples {
+			pgm.Set16(x, 0, sample)
+		}
+		img := pgm.Image()
+		if img.ColorModel() != test.model || img.Bounds() != image.Rect(0, 0, len(test.samples), 2) {
+			t.Fatalf("maxval %d : modèle %v, limites %v", test.max, img.ColorModel(), img.Bounds())
+		}
+		for x, want := range test.colors {
+			if got := img.At(x, 0); got != want {
+				t.Errorf("maxval %d : At(%d, 0) vaut %v, %v attendu", test.max, x, got, want)
+			}
+		}
+
+		// draw.Draw écrit dans l'image PGM à travers l'adaptateur
+		draw.Draw(img, image.Rect(0, 1, len(test.samples), 2), image.NewUniform(test.colors[len(test.colors)-1]), image.Point{}, draw.Src)
+		for x := range test.samples {
+			if got := pgm.At16(x, 1); got != test.max {
+				t.Errorf("maxval %d : après draw.Draw, pixel (%d, 1) vaut %d, %d attendu", test.max, x, got, test.max)
+			}
+		}
+
+		// L'encodage PNG conserve les couleurs exposées
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for x, want := range test.colors {
+			if got := test.model.Convert(decoded.At(x, 0)); got != want {
+				t.Errorf("maxval %d : pixel PNG (%d, 0) vaut %v, %v attendu", test.max, x, got, want)
+			}
+		}
+	}
+}
+
+func TestFromImage(t *testing.T) {
+	gray := image.NewGray(image.Rect(2, 3, 5, 4))
+	gray.SetGray(3, 3, color.Gray{200})
+	pgm := FromImage(gray)
+	if width, height := pgm.Size(); width != 3 || height != 1 || pgm.MaxValue() != 255 {
+		t.Fatalf("%d × %d, maxval %d ; 3 × 1, maxval 255 attendu", width, height, pgm.MaxValue())
+	}
+	if pgm.At16(0, 0) != 0 || pgm.At16(1, 0) != 200 {
+		t.Errorf("pixels %v, [0 200 0] attendu", pgm.Pix())
+	}
+
+	gray16 := image.NewGray16(image.Rect(0, 0, 2, 1))
+	gray16.SetGray16(1, 0, color.Gray16{Y: 1234})
+	pgm = FromImage(gray16)
+	if pgm.MaxValue() != 65535 || pgm.At16(1, 0) != 1234 {
+		t.Errorf("16 bits : maxval %d, pixel %d ; 65535 et 1234 attendus", pgm.MaxValue(), pgm.At16(1, 0))
+	}
+	if err := pgm.Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,20 +1,31 @@
 package pgm
 
 import (
-	"bufio"
+	"Netpbm/common"
+	"Netpbm/header"
+	"encoding/binary"
 	"fmt"
+	"io"
 	"os"
+	"slices"
 	"strconv"
 )
 
 // PGM struct represents a PGM image.
+// Les valeurs des pixels sont stockées sur 16 bits afin de prendre en charge
+// les valeurs maximales allant jusqu'à 65535, dans un tampon contigu où le pixel
+// (x, y) se trouve à l'indice y*stride + x.
 type PGM struct {
-	data          [][]uint8
+	pix           []uint16
+	stride        int
 	width, height int
 	magicNumber   string
+	comments      []string
 	max           int
 }
 
+var _ common.Image = (*PGM)(nil)
+
 // ReadPGM lit une image PGM à partir d'un fichier et retourne une structure représentant l'image.
 func ReadPGM(filename string) (*PGM, error) {
 	file, err := os.Open(filename)
@@ -23,121 +34,390 @@ func ReadPGM(filename string) (*PGM, error) {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	scanner.Split(bufio.ScanWords)
-
-	// Lire le numéro magique
-	scanner.Scan()
-	magicNumber := scanner.Text()
-
-	// Lire la largeur et la hauteur
-	scanner.Scan()
-	width, _ := strconv.Atoi(scanner.Text())
-	scanner.Scan()
-	height, _ := strconv.Atoi(scanner.Text())
-
-	// Lire la valeur maximale
-	scanner.Scan()
-	max, _ := strconv.Atoi(scanner.Text())
-
-	// Lire les données de l'image
-	data := make([][]uint8, height)
-	for i := range data {
-		data[i] = make([]uint8, width)
-		for j := range data[i] {
-			scanner.Scan()
-			val, _ := strconv.Atoi(scanner.Text())
-			data[i][j] = uint8(val)
-		}
+	return Decode(file)
+}
+
+// Decode lit une image PGM depuis r et renvoie une structure représentant l'image.
+// Les limites header.DefaultLimits sont appliquées.
+func Decode(r io.Reader) (*PGM, error) {
+	return DecodeWithLimits(r, header.DefaultLimits)
+}
+
+// DecodeWithLimits lit une image PGM depuis r en refusant, avant toute allocation,
+// les images qui dépassent limits. Les pixels sont alloués au fur et à mesure de la
+// lecture, de sorte qu'un fichier tronqué échoue sans réserver la taille annoncée.
+func DecodeWithLimits(r io.Reader, limits header.Limits) (*PGM, error) {
+	reader := header.NewReader(r)
+
+	pgm, err := readHeader(reader, limits)
+	if err != nil {
+		return nil, err
 	}
 
+	if pgm.magicNumber == "P2" {
+		pgm.pix, err = readPlain(reader, pgm.width, pgm.height, pgm.max)
+	} else {
+		pgm.pix, err = readRaw(reader, pgm.width, pgm.height, pgm.max)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return pgm, nil
+}
+
+// readHeader lit l'en-tête d'une image PGM (numéro magique, dimensions et valeur maximale)
+// et renvoie une image sans pixels si elle respecte limits.
+func readHeader(reader *header.Reader, limits header.Limits) (*PGM, error) {
+	h, err := header.Read(reader, "P2", "P5")
+	if err != nil {
+		return nil, err
+	}
+	if err := limits.Check(reader, h); err != nil {
+		return nil, err
+	}
 	return &PGM{
-		data:        data,
-		width:       width,
-		height:      height,
-		magicNumber: magicNumber,
-		max:         max,
+		stride:      h.Width,
+		width:       h.Width,
+		height:      h.Height,
+		magicNumber: h.MagicNumber,
+		comments:    h.Comments,
+		max:         h.MaxValue,
 	}, nil
 }
 
+// readPlain lit les pixels d'une image P2, écrits en décimal et séparés par des espaces.
+func readPlain(reader *header.Reader, width, height, max int) ([]uint16, error) {
+	var pix []uint16
+	for y := 0; y < height; y++ {
+		pix = slices.Grow(pix, width)[:len(pix)+width]
+		if err := readPlainRow(reader, pix[y*width:(y+1)*width], max); err != nil {
+			return nil, err
+		}
+	}
+	return pix, nil
+}
+
+// readPlainRow lit une ligne de pixels d'une image P2.
+func readPlainRow(reader *header.Reader, row []uint16, max int) error {
+	for x := range row {
+		val, err := reader.ReadSample(max)
+		if err != nil {
+			return err
+		}
+		row[x] = val
+	}
+	return nil
+}
+
+// readRaw lit les pixels d'une image P5, à raison d'un octet par pixel,
+// ou de deux octets en gros-boutiste si la valeur maximale dépasse 255.
+func readRaw(reader *header.Reader, width, height, max int) ([]uint16, error) {
+	var pix []uint16
+	raw := make([]byte, header.SampleSize(max)*width)
+	for y := 0; y < height; y++ {
+		pix = slices.Grow(pix, width)[:len(pix)+width]
+		if err := readRawRow(reader, raw, pix[y*width:(y+1)*width], max); err != nil {
+			return nil, err
+		}
+	}
+	return pix, nil
+}
+
+// readRawRow lit une ligne de pixels d'une image P5, en utilisant raw comme tampon.
+func readRawRow(reader *header.Reader, raw []byte, row []uint16, max int) error {
+	size := header.SampleSize(max)
+	if err := reader.ReadFull(raw, "pixels"); err != nil {
+		return err
+	}
+	for x := range row {
+		if size == 1 {
+			row[x] = uint16(raw[x])
+		} else {
+			row[x] = binary.BigEndian.Uint16(raw[2*x:])
+		}
+		if int(row[x]) > max {
+			return sampleError(reader, row[x], max, len(raw)-size*x)
+		}
+	}
+	return nil
+}
+
+// sampleError signale un échantillon binaire supérieur à la valeur maximale,
+// situé back octets avant la position courante du lecteur.
+func sampleError(reader *header.Reader, value uint16, max int, back int) error {
+	err := reader.NewError("pixels", header.ErrInvalidValue, fmt.Sprintf("entier entre 0 et %d", max), fmt.Sprint(value))
+	err.Offset -= int64(back)
+	return err
+}
+
 // Size renvoie la largeur et la hauteur de l'image.
 func (pgm *PGM) Size() (int, int) {
 	return pgm.width, pgm.height
 }
 
 // At renvoie la valeur du pixel à la position (x, y).
+// Si la valeur maximale dépasse 255, la valeur est ramenée dans la plage [0, 255] ;
+// At16 renvoie la valeur exacte.
 func (pgm *PGM) At(x, y int) uint8 {
-	return pgm.data[y][x]
+	value := pgm.At16(x, y)
+	if pgm.max <= 255 {
+		return uint8(value)
+	}
+	return uint8(header.ScaleSample(value, pgm.max, 255))
 }
 
 // Set définit la valeur du pixel à la position (x, y).
+// Si la valeur maximale dépasse 255, value est prise dans la plage [0, 255] et convertie
+// vers la plage [0, MaxValue()] ; Set16 définit la valeur exacte.
 func (pgm *PGM) Set(x, y int, value uint8) {
-	pgm.data[y][x] = value
+	if pgm.max <= 255 {
+		pgm.Set16(x, y, uint16(value))
+		return
+	}
+	pgm.Set16(x, y, header.ScaleSample(uint16(value), 255, pgm.max))
+}
+
+// At16 renvoie la valeur du pixel à la position (x, y), dans la plage [0, MaxValue()].
+func (pgm *PGM) At16(x, y int) uint16 {
+	return pgm.row(y)[x]
+}
+
+// Set16 définit la valeur du pixel à la position (x, y), dans la plage [0, MaxValue()].
+func (pgm *PGM) Set16(x, y int, value uint16) {
+	pgm.row(y)[x] = value
+}
+
+// row renvoie la ligne y de l'image, qui partage le tampon des pixels.
+func (pgm *PGM) row(y int) []uint16 {
+	return pgm.pix[y*pgm.stride : y*pgm.stride+pgm.width]
+}
+
+// Pix renvoie le tampon contigu des pixels, sans copie : le pixel (x, y) se trouve
+// à l'indice y*Stride() + x. Les modifications du tampon sont visibles dans l'image.
+func (pgm *PGM) Pix() []uint16 {
+	return pgm.pix
+}
+
+// Stride renvoie l'écart, en nombre de pixels, entre deux lignes consécutives de Pix.
+func (pgm *PGM) Stride() int {
+	return pgm.stride
 }
 
 // Save enregistre l'image PGM dans un fichier et renvoie une erreur en cas de problème.
+// Une erreur est renvoyée, sans créer le fichier, si Validate échoue.
+// Les pixels sont écrits en binaire si le numéro magique est P5, en texte sinon.
 func (pgm *PGM) Save(filename string) error {
+	if err := pgm.Validate(); err != nil {
+		return err
+	}
+
 	file, err := os.Create(filename)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	writer := bufio.NewWriter(file)
-	fmt.Fprintf(writer, "%s\n%d %d\n%d\n", pgm.magicNumber, pgm.width, pgm.height, pgm.max)
-	for _, row := range pgm.data {
-		for _, val := range row {
-			fmt.Fprintf(writer, "%d ", val)
-		}
-		fmt.Fprintln(writer)
+	if err := pgm.encode(file, header.DefaultWriteOptions); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Encode écrit l'image PGM dans w au format indiqué par son numéro magique.
+// Une erreur est renvoyée, sans rien écrire, si Validate échoue.
+// Les options header.DefaultWriteOptions sont appliquées.
+func (pgm *PGM) Encode(w io.Writer) error {
+	return pgm.EncodeWithOptions(w, header.DefaultWriteOptions)
+}
+
+// EncodeWithOptions écrit l'image PGM dans w, en mettant en forme les pixels selon
+// options si le numéro magique est P2.
+func (pgm *PGM) EncodeWithOptions(w io.Writer, options header.WriteOptions) error {
+	if err := options.Check(); err != nil {
+		return err
+	}
+	if err := pgm.Validate(); err != nil {
+		return err
+	}
+	return pgm.encode(w, options)
+}
+
+// encode écrit l'en-tête et les pixels de l'image PGM dans w.
+func (pgm *PGM) encode(w io.Writer, options header.WriteOptions) error {
+	writer := newTextWriter(w, options)
+	writeHeader(writer, pgm.magicNumber, pgm.comments, pgm.width, pgm.height, pgm.max)
+	for y := 0; y < pgm.height; y++ {
+		writeRow(writer, pgm.row(y), pgm.magicNumber, pgm.max)
 	}
 	return writer.Flush()
 }
 
+// newTextWriter renvoie un header.TextWriter qui écrit dans w selon options.
+// Un séparateur vide est remplacé par une espace, les échantillons devant être séparés.
+func newTextWriter(w io.Writer, options header.WriteOptions) *header.TextWriter {
+	if options.Separator == "" {
+		options.Separator = " "
+	}
+	return header.NewTextWriter(w, options)
+}
+
+// writeHeader écrit l'en-tête d'une image PGM.
+// Les commentaires sont écrits juste après le numéro magique.
+func writeHeader(writer *header.TextWriter, magicNumber string, comments []string, width, height, max int) {
+	fmt.Fprintf(writer, "%s\n", magicNumber)
+	header.WriteComments(writer, comments)
+	fmt.Fprintf(writer, "%d %d\n%d\n", width, height, max)
+}
+
+// writeRow écrit une ligne de pixels, en binaire si le numéro magique est P5, en texte sinon.
+// Les erreurs d'écriture sont conservées par writer et renvoyées par Flush.
+func writeRow(writer *header.TextWriter, row []uint16, magicNumber string, max int) {
+	if magicNumber == "P5" {
+		size := header.SampleSize(max)
+		raw := make([]byte, size*len(row))
+		for x, val := range row {
+			if size == 1 {
+				raw[x] = uint8(val)
+			} else {
+				binary.BigEndian.PutUint16(raw[2*x:], val)
+			}
+		}
+		writer.Write(raw)
+		return
+	}
+	for _, val := range row {
+		writer.WriteValue(strconv.Itoa(int(val)))
+	}
+	writer.EndLine()
+}
+
 // Invert inverse les couleurs de l'image PGM.
 func (pgm *PGM) Invert() {
-	for y := range pgm.data {
-		for x := range pgm.data[y] {
-			pgm.data[y][x] = uint8(pgm.max) - pgm.data[y][x]
-		}
+	for y := 0; y < pgm.height; y++ {
+		InvertRow(pgm.row(y), uint16(pgm.max))
 	}
 }
 
 // Flip retourne l'image PGM horizontalement.
 func (pgm *PGM) Flip() {
-	for y := range pgm.data {
-		for x := 0; x < pgm.width/2; x++ {
-			pgm.data[y][x], pgm.data[y][pgm.width-x-1] = pgm.data[y][pgm.width-x-1], pgm.data[y][x]
-		}
+	for y := 0; y < pgm.height; y++ {
+		FlipRow(pgm.row(y))
 	}
 }
 
 // Flop retourne l'image PGM verticalement.
 func (pgm *PGM) Flop() {
 	for y := 0; y < pgm.height/2; y++ {
-		pgm.data[y], pgm.data[pgm.height-y-1] = pgm.data[pgm.height-y-1], pgm.data[y]
+		top, bottom := pgm.row(y), pgm.row(pgm.height-y-1)
+		for x := range top {
+			top[x], bottom[x] = bottom[x], top[x]
+		}
 	}
 }
 
+// MagicNumber renvoie le numéro magique de l'image PGM.
+func (pgm *PGM) MagicNumber() string {
+	return pgm.magicNumber
+}
+
 // SetMagicNumber définit le numéro magique de l'image PGM.
 func (pgm *PGM) SetMagicNumber(magicNumber string) {
 	pgm.magicNumber = magicNumber
 }
 
-// SetMaxValue définit la valeur maximale de l'image PGM.
+// Comments renvoie une copie des commentaires de l'en-tête, sans le # initial.
+func (pgm *PGM) Comments() []string {
+	return slices.Clone(pgm.comments)
+}
+
+// SetComments définit les commentaires écrits dans l'en-tête par Save et Encode.
+// Un commentaire contenant des sauts de ligne est écrit sur plusieurs lignes.
+func (pgm *PGM) SetComments(comments []string) {
+	pgm.comments = slices.Clone(comments)
+}
+
+// MaxValue renvoie la valeur maximale de l'image PGM.
+func (pgm *PGM) MaxValue() uint16 {
+	return uint16(pgm.max)
+}
+
+// SetMaxValue définit la valeur maximale de l'image PGM sans modifier les pixels.
+// Utilisez ScaleMaxValue pour convertir les pixels vers la nouvelle plage.
 func (pgm *PGM) SetMaxValue(maxValue uint8) {
 	pgm.max = int(maxValue)
 }
 
+// SetMaxValue16 fonctionne comme SetMaxValue pour une valeur maximale allant jusqu'à 65535.
+func (pgm *PGM) SetMaxValue16(maxValue uint16) {
+	pgm.max = int(maxValue)
+}
+
+// ScaleMaxValue définit la valeur maximale de l'image PGM et convertit chaque pixel
+// proportionnellement vers la nouvelle plage, en arrondissant au plus proche.
+// Un pixel au-delà de l'ancienne valeur maximale devient la nouvelle valeur maximale.
+// Une valeur maximale nulle est refusée et l'image reste inchangée.
+func (pgm *PGM) ScaleMaxValue(maxValue uint16) error {
+	if maxValue == 0 {
+		return fmt.Errorf("valeur maximale non valide : %d", maxValue)
+	}
+	for y := 0; y < pgm.height; y++ {
+		row := pgm.row(y)
+		for x := range row {
+			row[x] = header.ScaleSample(row[x], pgm.max, int(maxValue))
+		}
+	}
+	pgm.max = int(maxValue)
+	return nil
+}
+
+// Validate vérifie la cohérence de l'image : numéro magique P2 ou P5, dimensions
+// positives, tampon de pixels assez grand pour le pas annoncé, valeur maximale comprise
+// entre 1 et 65535 et aucun pixel au-delà de celle-ci.
+func (pgm *PGM) Validate() error {
+	if pgm.magicNumber != "P2" && pgm.magicNumber != "P5" {
+		return fmt.Errorf("numéro magique non valide : %q", pgm.magicNumber)
+	}
+	if pgm.width < 0 || pgm.height < 0 {
+		return fmt.Errorf("dimensions non valides : %d × %d", pgm.width, pgm.height)
+	}
+	if pgm.height > 0 && (pgm.stride < pgm.width || len(pgm.pix) < (pgm.height-1)*pgm.stride+pgm.width) {
+		return fmt.Errorf("%d pixels avec un pas de %d pour une image de %d × %d", len(pgm.pix), pgm.stride, pgm.width, pgm.height)
+	}
+	if pgm.max < 1 || pgm.max > 65535 {
+		return fmt.Errorf("valeur maximale non valide : %d", pgm.max)
+	}
+	for y := 0; y < pgm.height; y++ {
+		for x, val := range pgm.row(y) {
+			if int(val) > pgm.max {
+				return fmt.Errorf("le pixel (%d, %d) vaut %d, au-delà de la valeur maximale %d", x, y, val, pgm.max)
+			}
+		}
+	}
+	return nil
+}
+
 // Rotate90CW fait pivoter l'image PGM de 90° dans le sens des aiguilles d'une montre.
 func (pgm *PGM) Rotate90CW() {
-	newData := make([][]uint8, pgm.width)
-	for i := range newData {
-		newData[i] = make([]uint8, pgm.height)
-		for j := range newData[i] {
-			newData[i][j] = pgm.data[pgm.height-j-1][i]
+	pix := make([]uint16, pgm.width*pgm.height)
+	for i := 0; i < pgm.width; i++ {
+		for j := 0; j < pgm.height; j++ {
+			pix[i*pgm.height+j] = pgm.At16(i, pgm.height-j-1)
 		}
 	}
-	pgm.data = newData
+	pgm.pix, pgm.stride = pix, pgm.height
 	pgm.width, pgm.height = pgm.height, pgm.width
 }
+
+// NewPGM crée une nouvelle image PGM (P2) noire avec la largeur et la hauteur spécifiées
+// et une valeur maximale de 255.
+func NewPGM(width, height int) *PGM {
+	return &PGM{
+		pix:         make([]uint16, width*height),
+		stride:      width,
+		width:       width,
+		height:      height,
+		magicNumber: "P2",
+		max:         255,
+	}
+}
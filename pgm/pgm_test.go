@@ -0,0 +1,164 @@
+package pgm
+
+import (
+	"Netpbm/internal/imagetest"
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// randomPGM renvoie une image aléatoire de valeur maximale max.
+func randomPGM(rng *rand.Rand, width, height int, max uint16) *PGM {
+	pgm := NewPGM(width, height)
+	pgm.SetMaxValue16(max)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			pgm.Set16(x, y, uint16(rng.Intn(int(max)+1)))
+		}
+	}
+	// Les valeurs extrêmes sont toujours présentes
+	if width > 1 && height > 0 {
+		pgm.Set16(0, 0, 0)
+		pgm.Set16(width-1, height-1, max)
+	}
+	return pgm
+}
+
+func TestRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, magicNumber := range []string{"P2", "P5"} {
+		for _, max := range []uint16{255, 4095, 65535} {
+			for _, width := range []int{1, 7, 13, 100} {
+				pgm := randomPGM(rng, width, 3, max)
+				pgm.SetMagicNumber(magicNumber)
+				imagetest.RoundTrip(t, pgm, Decode)
+			}
+		}
+	}
+}
+
+func TestEncodeRaw(t *testing.T) {
+	tests := []struct {
+		max    uint16
+		pixels []uint16
+		want   string
+	}{
+		{255, []uint16{1, 255, 10}, "P5\n3 1\n255\n\x01\xff\x0a"},
+		// Au-delà de 255, deux octets par échantillon, l'octet de poids fort en premier
+		{4095, []uint16{1, 4095, 256}, "P5\n3 1\n4095\n\x00\x01\x0f\xff\x01\x00"},
+	}
+	for _, test := range tests {
+		pgm := NewPGM(len(test.pixels), 1)
+		pgm.SetMagicNumber("P5")
+		pgm.SetMaxValue16(test.max)
+		for x, val := range test.pixels {
+			pgm.Set16(x, 0, val)
+		}
+		var buf bytes.Buffer
+		if err := pgm.Encode(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != test.want {
+			t.Errorf("maxval %d : %q, %q attendu", test.max, buf.String(), test.want)
+		}
+	}
+}
+
+func TestDecodeRawWhitespaceSamples(t *testing.T) {
+	// Un seul caractère d'espacement sépare la valeur maximale des pixels : les octets
+	// suivants sont des pixels, même s'ils ressemblent à des espaces ou à un commentaire
+	tests := []struct {
+		input string
+		want  []uint16
+	}{
+		{"P5 4 1 255\n\n \t#", []uint16{'\n', ' ', '\t', '#'}},
+		{"P5 2 1 255 \r\n", []uint16{'\r', '\n'}},
+		{"P5 2 1 4095\n\x0a\x20\x00\x0d", []uint16{0x0a20, 0x000d}},
+	}
+	for _, test := range tests {
+		pgm, err := Decode(bytes.NewReader([]byte(test.input)))
+		if err != nil {
+			t.Fatalf("%q : %v", test.input, err)
+		}
+		for x, want := range test.want {
+			if got := pgm.At16(x, 0); got != want {
+				t.Errorf("%q : pixel %d vaut %d, %d attendu", test.input, x, got, want)
+			}
+		}
+	}
+}
+
+func TestEightBitAccessors(t *testing.T) {
+	pgm := NewPGM(2, 1)
+	var max uint8 = 200
+	pgm.SetMaxValue(max)
+	pgm.Set(0, 0, 150)
+	if got := pgm.At(0, 0); got != 150 || pgm.At16(0, 0) != 150 {
+		t.Errorf("maxval 200 : At %d, At16 %d, 150 attendu", got, pgm.At16(0, 0))
+	}
+
+	// Au-delà de 255, les accesseurs 8 bits travaillent dans la plage [0, 255]
+	pgm.SetMaxValue16(4095)
+	pgm.Set(1, 0, 255)
+	if got := pgm.At16(1, 0); got != 4095 {
+		t.Errorf("maxval 4095 : Set(255) donne %d, 4095 attendu", got)
+	}
+	pgm.Set16(0, 0, 2048)
+	if got := pgm.At(0, 0); got != 128 {
+		t.Errorf("maxval 4095 : At %d pour 2048, 128 attendu", got)
+	}
+}
+
+func TestScaleMaxValue(t *testing.T) {
+	pgm := NewPGM(4, 1)
+	pgm.SetMaxValue(255)
+	for x, value := range []uint16{8, 9, 128, 255} {
+		pgm.Set16(x, 0, value)
+	}
+	if err := pgm.ScaleMaxValue(15); err != nil {
+		t.Fatal(err)
+	}
+	// 8 × 15 / 255 ≈ 0,47 et 9 × 15 / 255 ≈ 0,53 : arrondi au plus proche
+	for x, want := range []uint16{0, 1, 8, 15} {
+		if got := pgm.At16(x, 0); got != want {
+			t.Errorf("255 → 15 : pixel %d vaut %d, %d attendu", x, got, want)
+		}
+	}
+
+	// Un pixel au-delà de la valeur maximale est ramené à la nouvelle valeur maximale
+	pgm.SetMaxValue(1)
+	pgm.Set16(0, 0, 65535)
+	if err := pgm.ScaleMaxValue(65535); err != nil {
+		t.Fatal(err)
+	}
+	if got := pgm.At16(0, 0); got != 65535 {
+		t.Errorf("1 → 65535 : pixel 65535 devient %d, 65535 attendu", got)
+	}
+
+	if err := pgm.ScaleMaxValue(0); err == nil || pgm.MaxValue() != 65535 {
+		t.Errorf("ScaleMaxValue(0) : erreur %v, valeur maximale %d", err, pgm.MaxValue())
+	}
+}
+
+func TestRefuseInvalid(t *testing.T) {
+	badMagic := NewPGM(2, 1)
+	badMagic.SetMagicNumber("P3")
+	overMax := NewPGM(2, 1)
+	overMax.SetMaxValue(15)
+	overMax.Set16(1, 0, 16)
+	for name, pgm := range map[string]*PGM{"nombre magique": badMagic, "valeur trop grande": overMax} {
+		filename := filepath.Join(t.TempDir(), "image.pgm")
+		if err := pgm.Save(filename); err == nil {
+			t.Errorf("%s : Save accepte une image non valide", name)
+		}
+		if _, err := os.Stat(filename); !os.IsNotExist(err) {
+			t.Errorf("%s : Save a créé le fichier (%v)", name, err)
+		}
+		var buf bytes.Buffer
+		if err := pgm.Encode(&buf); err == nil || buf.Len() > 0 {
+			t.Errorf("%s : Encode écrit %d octets, erreur %v", name, buf.Len(), err)
+		}
+	}
+}
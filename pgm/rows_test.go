@@ -0,0 +1,105 @@
+package pgm
+
+import (
+	"Netpbm/internal/imagetest"
+	"bytes"
+	"io"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestRowRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	for _, magicNumber := range []string{"P2", "P5"} {
+		for _, max := range []uint16{255, 65535} {
+			want := randomPGM(rng, 9, 4, max)
+			want.SetMagicNumber(magicNumber)
+			var buf bytes.Buffer
+			rw, err := NewRowWriter(&buf, 9, 4, magicNumber, max)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for y := 0; y < 4; y++ {
+				if err := rw.WriteRow(want.row(y)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := rw.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			rr, err := NewRowReader(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if width, height := rr.Size(); width != 9 || height != 4 || rr.MagicNumber() != magicNumber || rr.MaxValue() != max {
+				t.Fatalf("%s %d × %d, maxval %d", rr.MagicNumber(), width, height, rr.MaxValue())
+			}
+			row := make([]uint16, 9)
+			for y := 0; y < 4; y++ {
+				if err := rr.ReadRow(row); err != nil {
+					t.Fatal(err)
+				}
+				if !slices.Equal(row, want.row(y)) {
+					t.Errorf("%s, maxval %d : ligne %d vaut %v, %v attendu", magicNumber, max, y, row, want.row(y))
+				}
+			}
+			if err := rr.ReadRow(row); err != io.EOF {
+				t.Errorf("après la dernière ligne : %v, io.EOF attendu", err)
+			}
+
+			// Le résultat se relit aussi d'un bloc
+			decoded, err := Decode(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			imagetest.Equal(t, decoded, want)
+		}
+	}
+}
+
+func TestRowErrors(t *testing.T) {
+	var buf bytes.Buffer
+	rw, err := NewRowWriter(&buf, 3, 2, "P5", 255)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.WriteRow(make([]uint16, 2)); err == nil {
+		t.Error("WriteRow accepte une ligne de 2 pixels pour une largeur de 3")
+	}
+	if err := rw.WriteRow(make([]uint16, 3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.Close(); err == nil {
+		t.Error("Close accepte une image à laquelle il manque une ligne")
+	}
+
+	rr, err := NewRowReader(bytes.NewReader([]byte("P2 3 1 255 1 2 3")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rr.ReadRow(make([]uint16, 4)); err == nil {
+		t.Error("ReadRow accepte une ligne de 4 pixels pour une largeur de 3")
+	}
+}
+
+func TestRowOperations(t *testing.T) {
+	// Trois images identiques, tirées avec la même graine
+	image := func() *PGM { return randomPGM(rand.New(rand.NewSource(6)), 11, 3, 4095) }
+	pgm, inverted, flipped := image(), image(), image()
+	inverted.Invert()
+	flipped.Flip()
+	for y := 0; y < 3; y++ {
+		row := slices.Clone(pgm.row(y))
+		InvertRow(row, pgm.MaxValue())
+		if !slices.Equal(row, inverted.row(y)) {
+			t.Errorf("InvertRow : ligne %d vaut %v, %v attendu", y, row, inverted.row(y))
+		}
+		row = slices.Clone(pgm.row(y))
+		FlipRow(row)
+		if !slices.Equal(row, flipped.row(y)) {
+			t.Errorf("FlipRow : ligne %d vaut %v, %v attendu", y, row, flipped.row(y))
+		}
+	}
+}
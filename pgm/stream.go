@@ -0,0 +1,52 @@
+package pgm
+
+import (
+	"Netpbm/header"
+	"io"
+)
+
+// Reader lit successivement les images PGM concaténées dans un même flux.
+type Reader struct {
+	reader *header.Reader
+	limits header.Limits
+}
+
+// NewReader renvoie un Reader qui lit les images de r avec les limites header.DefaultLimits.
+func NewReader(r io.Reader) *Reader {
+	return NewReaderWithLimits(r, header.DefaultLimits)
+}
+
+// NewReaderWithLimits renvoie un Reader qui applique limits à chacune des images de r.
+func NewReaderWithLimits(r io.Reader, limits header.Limits) *Reader {
+	return &Reader{reader: header.NewReader(r), limits: limits}
+}
+
+// Next lit l'image suivante du flux. io.EOF est renvoyé lorsqu'il ne reste plus
+// que des caractères d'espacement ou des commentaires ; toute autre erreur interrompt la lecture.
+func (r *Reader) Next() (*PGM, error) {
+	if err := r.reader.SkipSpace(); err != nil {
+		return nil, err
+	}
+	return DecodeWithLimits(r.reader, r.limits)
+}
+
+// Writer écrit plusieurs images PGM à la suite dans un même flux.
+type Writer struct {
+	w       io.Writer
+	options header.WriteOptions
+}
+
+// NewWriter renvoie un Writer qui écrit dans w avec les options header.DefaultWriteOptions.
+func NewWriter(w io.Writer) *Writer {
+	return NewWriterWithOptions(w, header.DefaultWriteOptions)
+}
+
+// NewWriterWithOptions renvoie un Writer qui met en forme les images en texte selon options.
+func NewWriterWithOptions(w io.Writer, options header.WriteOptions) *Writer {
+	return &Writer{w: w, options: options}
+}
+
+// Write ajoute l'image PGM au flux, dans le format indiqué par son numéro magique.
+func (w *Writer) Write(pgm *PGM) error {
+	return pgm.EncodeWithOptions(w.w, w.options)
+}
@@ -0,0 +1,74 @@
+package pgm
+
+import (
+	"Netpbm/internal/imagetest"
+	"bytes"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReaderNext(t *testing.T) {
+	// Images P2 et P5 mélangées ; la dernière ligne binaire se termine par des octets qui
+	// ressemblent à des espaces ou à un commentaire, suivis d'espaces et d'un commentaire final
+	input := "P2 2 1 255 1 2\n" +
+		"# deuxième image\nP5 3 1 255\n\n \t" +
+		"P5 2 1 4095\n\x00\x23\x0d\x0a" +
+		"P2 1 1 15\n7\n\n# fin du flux\n  \n"
+	want := []struct {
+		magicNumber string
+		pixels      []uint16
+		comments    []string
+	}{
+		{"P2", []uint16{1, 2}, nil},
+		{"P5", []uint16{'\n', ' ', '\t'}, []string{"deuxième image"}},
+		{"P5", []uint16{0x0023, 0x0d0a}, nil},
+		{"P2", []uint16{7}, nil},
+	}
+	r := NewReader(strings.NewReader(input))
+	for i, w := range want {
+		pgm, err := r.Next()
+		if err != nil {
+			t.Fatalf("image %d : %v", i, err)
+		}
+		if pgm.MagicNumber() != w.magicNumber || !slices.Equal(pgm.Pix(), w.pixels) {
+			t.Errorf("image %d : %s %v, %s %v attendu", i, pgm.MagicNumber(), pgm.Pix(), w.magicNumber, w.pixels)
+		}
+		if !slices.Equal(pgm.Comments(), w.comments) {
+			t.Errorf("image %d : commentaires %q, %q attendus", i, pgm.Comments(), w.comments)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if pgm, err := r.Next(); err != io.EOF {
+			t.Fatalf("après la dernière image : %v, %v, io.EOF attendu", pgm, err)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	first, second := NewPGM(3, 2), NewPGM(1, 1)
+	first.SetMagicNumber("P5")
+	first.SetMaxValue16(4095)
+	first.Set16(2, 1, 4095)
+	second.Set(0, 0, '\n')
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, pgm := range []*PGM{first, second, first} {
+		if err := w.Write(pgm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := NewReader(&buf)
+	for i, want := range []*PGM{first, second, first} {
+		got, err := r.Next()
+		if err != nil {
+			t.Fatalf("image %d : %v", i, err)
+		}
+		imagetest.Equal(t, got, want)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("%v, io.EOF attendu", err)
+	}
+}
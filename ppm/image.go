@@ -0,0 +1,131 @@
+package ppm
+
+import (
+	"Netpbm/header"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+)
+
+// Les formats P3 et P6 sont enregistrés auprès du paquet image, de sorte
+// qu'un import de ce paquet suffit pour qu'image.Decode reconnaisse les fichiers PPM.
+func init() {
+	image.RegisterFormat("ppm", "P3", decodeImage, DecodeConfig)
+	image.RegisterFormat("ppm", "P6", decodeImage, DecodeConfig)
+}
+
+// decodeImage lit une image PPM depuis r et renvoie son adaptateur image.Image.
+func decodeImage(r io.Reader) (image.Image, error) {
+	ppm, err := Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	return ppm.Image(), nil
+}
+
+// DecodeConfig renvoie le modèle de couleur et les dimensions d'une image PPM
+// en ne lisant que son en-tête.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	ppm, err := readHeader(header.NewReader(r), header.Limits{})
+	if err != nil {
+		return image.Config{}, err
+	}
+	colorModel := color.RGBAModel
+	if ppm.max > 255 {
+		colorModel = color.RGBA64Model
+	}
+	return image.Config{ColorModel: colorModel, Width: ppm.width, Height: ppm.height}, nil
+}
+
+// Image adapte une image PPM aux interfaces image.Image et draw.Image.
+// Les pixels sont exposés en color.RGBA si la valeur maximale tient sur 8 bits,
+// en color.RGBA64 sinon, après mise à l'échelle depuis la plage [0, max].
+type Image struct {
+	ppm *PPM
+}
+
+var _ draw.Image = (*Image)(nil)
+
+// Image renvoie un adaptateur qui partage les pixels de l'image PPM.
+func (ppm *PPM) Image() *Image {
+	return &Image{ppm: ppm}
+}
+
+// PPM renvoie l'image PPM sous-jacente.
+func (img *Image) PPM() *PPM {
+	return img.ppm
+}
+
+// ColorModel renvoie le modèle de couleur de l'image.
+func (img *Image) ColorModel() color.Model {
+	if img.ppm.max <= 255 {
+		return color.RGBAModel
+	}
+	return color.RGBA64Model
+}
+
+// Bounds renvoie le rectangle occupé par l'image.
+func (img *Image) Bounds() image.Rectangle {
+	return image.Rect(0, 0, img.ppm.width, img.ppm.height)
+}
+
+// At renvoie la couleur du pixel en (x, y).
+func (img *Image) At(x, y int) color.Color {
+	if !(image.Point{X: x, Y: y}.In(img.Bounds())) || img.ppm.max < 1 {
+		return img.ColorModel().Convert(color.Transparent)
+	}
+	pixel, max := img.ppm.At16(x, y), int(img.ppm.max)
+	if max <= 255 {
+		return color.RGBA{
+			R: uint8(header.ScaleSample(pixel.R, max, 255)),
+			G: uint8(header.ScaleSample(pixel.G, max, 255)),
+			B: uint8(header.ScaleSample(pixel.B, max, 255)),
+			A: 255,
+		}
+	}
+	return color.RGBA64{
+		R: header.ScaleSample(pixel.R, max, 65535),
+		G: header.ScaleSample(pixel.G, max, 65535),
+		B: header.ScaleSample(pixel.B, max, 65535),
+		A: 65535,
+	}
+}
+
+// Set définit la couleur du pixel en (x, y). Les coordonnées hors de l'image sont ignorées.
+// La transparence n'est pas conservée : la couleur est composée sur du noir.
+func (img *Image) Set(x, y int, c color.Color) {
+	if !(image.Point{X: x, Y: y}.In(img.Bounds())) || img.ppm.max < 1 {
+		return
+	}
+	img.ppm.Set16(x, y, pixelFromColor(c, int(img.ppm.max)))
+}
+
+// pixelFromColor convertit une couleur quelconque en pixel dans la plage [0, max].
+func pixelFromColor(c color.Color, max int) Pixel16 {
+	r, g, b, _ := c.RGBA()
+	return Pixel16{
+		R: header.ScaleSample(uint16(r), 65535, max),
+		G: header.ScaleSample(uint16(g), 65535, max),
+		B: header.ScaleSample(uint16(b), 65535, max),
+	}
+}
+
+// FromImage crée une image PPM (P3) à partir d'une image quelconque.
+// La valeur maximale vaut 65535 si l'image source est en 16 bits, 255 sinon.
+func FromImage(src image.Image) *PPM {
+	bounds := src.Bounds()
+	max := 255
+	switch src.ColorModel() {
+	case color.Gray16Model, color.RGBA64Model, color.NRGBA64Model:
+		max = 65535
+	}
+	ppm := NewPPM(bounds.Dx(), bounds.Dy())
+	ppm.max = uint(max)
+	for y := 0; y < ppm.height; y++ {
+		for x := 0; x < ppm.width; x++ {
+			ppm.Set16(x, y, pixelFromColor(src.At(bounds.Min.X+x, bounds.Min.Y+y), max))
+		}
+	}
+	return ppm
+}
@@ -0,0 +1,89 @@
+package ppm
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"testing"
+)
+
+func TestImage(t *testing.T) {
+	tests := []struct {
+		max   uint16
+		model color.Model
+		// pixels associe des pixels de l'image PPM aux couleurs exposées par l'adaptateur
+		pixels []Pixel16
+		colors []color.Color
+	}{
+		{255, color.RGBAModel, []Pixel16{{0, 0, 0}, {10, 20, 255}},
+			[]color.Color{color.RGBA{0, 0, 0, 255}, color.RGBA{10, 20, 255, 255}}},
+		{15, color.RGBAModel, []Pixel16{{1, 0, 15}},
+			[]color.Color{color.RGBA{17, 0, 255, 255}}},
+		{4095, color.RGBA64Model, []Pixel16{{1, 2048, 4095}, {0, 0, 0}},
+			[]color.Color{color.RGBA64{16, 32776, 65535, 65535}, color.RGBA64{0, 0, 0, 65535}}},
+	}
+	for _, test := range tests {
+		ppm := NewPPM(len(test.pixels), 2)
+		ppm.SetMaxValue16(test.max)
+		for x, pixel := range test.pixels {
+			ppm.Set16(x, 0, pixel)
+		}
+		img := ppm.Image()
+		if img.ColorModel() != test.model || img.Bounds() != image.Rect(0, 0, len(test.pixels), 2) {
+			t.Fatalf("maxval %d : modèle %v, limites %v", test.max, img.ColorModel(), img.Bounds())
+		}
+		for x, want := range test.colors {
+			if got := img.At(x, 0); got != want {
+				t.Errorf("maxval %d : At(%d, 0) vaut %v, %v attendu", test.max, x, got, want)
+			}
+		}
+
+		// draw.Draw écrit dans l'image PPM à travers l'adaptateur
+		red := color.RGBA64{R: 65535, A: 65535}
+		draw.Draw(img, image.Rect(0, 1, len(test.pixels), 2), image.NewUniform(red), image.Point{}, draw.Src)
+		for x := range test.pixels {
+			if got, want := ppm.At16(x, 1), (Pixel16{R: test.max}); got != want {
+				t.Errorf("maxval %d : après draw.Draw, pixel (%d, 1) vaut %v, %v attendu", test.max, x, got, want)
+			}
+		}
+
+		// L'encodage PNG conserve les couleurs exposées
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for x, want := range test.colors {
+			if got := test.model.Convert(decoded.At(x, 0)); got != want {
+				t.Errorf("maxval %d : pixel PNG (%d, 0) vaut %v, %v attendu", test.max, x, got, want)
+			}
+		}
+	}
+}
+
+func TestFromImage(t *testing.T) {
+	rgba := image.NewRGBA(image.Rect(2, 3, 5, 4))
+	rgba.SetRGBA(3, 3, color.RGBA{R: 200, G: 100, B: 50, A: 255})
+	ppm := FromImage(rgba)
+	if width, height := ppm.Size(); width != 3 || height != 1 || ppm.MaxValue() != 255 {
+		t.Fatalf("%d × %d, maxval %d ; 3 × 1, maxval 255 attendu", width, height, ppm.MaxValue())
+	}
+	if got := ppm.At16(1, 0); got != (Pixel16{R: 200, G: 100, B: 50}) {
+		t.Errorf("pixel %v, {200 100 50} attendu", got)
+	}
+
+	rgba64 := image.NewRGBA64(image.Rect(0, 0, 2, 1))
+	rgba64.SetRGBA64(1, 0, color.RGBA64{R: 1234, G: 65535, B: 7, A: 65535})
+	ppm = FromImage(rgba64)
+	if got := ppm.At16(1, 0); ppm.MaxValue() != 65535 || got != (Pixel16{R: 1234, G: 65535, B: 7}) {
+		t.Errorf("16 bits : maxval %d, pixel %v ; 65535 et {1234 65535 7} attendus", ppm.MaxValue(), got)
+	}
+	if err := ppm.Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,10 +1,14 @@
 package ppm
 
 import (
-	"bufio"
+	"Netpbm/common"
+	"Netpbm/header"
+	"encoding/binary"
 	"fmt"
+	"io"
 	"math"
 	"os"
+	"slices"
 	"strconv"
 )
 
@@ -13,18 +17,30 @@ type Pixel struct {
 	R, G, B uint8
 }
 
+// Pixel16 représente un pixel dont les composantes sont stockées sur 16 bits afin
+// de prendre en charge les valeurs maximales allant jusqu'à 65535.
+type Pixel16 struct {
+	R, G, B uint16
+}
+
 type Point struct {
 	X, Y int
 }
 
 // PPM struct represents a PPM image.
+// Les pixels sont stockés dans un tampon contigu où le pixel (x, y) se trouve
+// à l'indice y*stride + x.
 type PPM struct {
-	data          [][]Pixel
+	pix           []Pixel16
+	stride        int
 	width, height int
 	magicNumber   string
+	comments      []string
 	max           uint
 }
 
+var _ common.Image = (*PPM)(nil)
+
 // ReadPPM lit une image PPM à partir d'un fichier et retourne une structure représentant l'image.
 func ReadPPM(filename string) (*PPM, error) {
 	file, err := os.Open(filename)
@@ -33,65 +49,129 @@ func ReadPPM(filename string) (*PPM, error) {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	scanner.Split(bufio.ScanWords)
-
-	// Lire le numéro magique
-	scanner.Scan()
-	magicNumber := scanner.Text()
-
-	// Lire la largeur et la hauteur
-	scanner.Scan()
-	width, _ := strconv.Atoi(scanner.Text())
-	scanner.Scan()
-	height, _ := strconv.Atoi(scanner.Text())
-
-	// Lire la valeur maximale
-	scanner.Scan()
-	max, _ := strconv.Atoi(scanner.Text())
-
-	// Lire les données de l'image
-	data := make([][]Pixel, height)
-	for i := range data {
-		data[i] = make([]Pixel, width)
-		for j := range data[i] {
-			var pixel Pixel
-
-			// Lire la composante rouge
-			scanner.Scan()
-			red, err := strconv.ParseUint(scanner.Text(), 10, 8)
-			if err != nil {
-				return nil, err
-			}
-			pixel.R = uint8(red)
+	return Decode(file)
+}
 
-			// Lire la composante verte
-			scanner.Scan()
-			green, err := strconv.ParseUint(scanner.Text(), 10, 8)
-			if err != nil {
-				return nil, err
-			}
-			pixel.G = uint8(green)
+// Decode lit une image PPM depuis r et renvoie une structure représentant l'image.
+// Les limites header.DefaultLimits sont appliquées.
+func Decode(r io.Reader) (*PPM, error) {
+	return DecodeWithLimits(r, header.DefaultLimits)
+}
+
+// DecodeWithLimits lit une image PPM depuis r en refusant, avant toute allocation,
+// les images qui dépassent limits. Les pixels sont alloués au fur et à mesure de la
+// lecture, de sorte qu'un fichier tronqué échoue sans réserver la taille annoncée.
+func DecodeWithLimits(r io.Reader, limits header.Limits) (*PPM, error) {
+	reader := header.NewReader(r)
+
+	ppm, err := readHeader(reader, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	if ppm.magicNumber == "P3" {
+		ppm.pix, err = readPlain(reader, ppm.width, ppm.height, int(ppm.max))
+	} else {
+		ppm.pix, err = readRaw(reader, ppm.width, ppm.height, int(ppm.max))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ppm, nil
+}
+
+// readHeader lit l'en-tête d'une image PPM (numéro magique, dimensions et valeur maximale)
+// et renvoie une image sans pixels si elle respecte limits.
+func readHeader(reader *header.Reader, limits header.Limits) (*PPM, error) {
+	h, err := header.Read(reader, "P3", "P6")
+	if err != nil {
+		return nil, err
+	}
+	if err := limits.Check(reader, h); err != nil {
+		return nil, err
+	}
+	return &PPM{
+		stride:      h.Width,
+		width:       h.Width,
+		height:      h.Height,
+		magicNumber: h.MagicNumber,
+		comments:    h.Comments,
+		max:         uint(h.MaxValue),
+	}, nil
+}
+
+// readPlain lit les pixels d'une image P3, chaque composante étant écrite en décimal.
+func readPlain(reader *header.Reader, width, height, max int) ([]Pixel16, error) {
+	var pix []Pixel16
+	for y := 0; y < height; y++ {
+		pix = slices.Grow(pix, width)[:len(pix)+width]
+		if err := readPlainRow(reader, pix[y*width:(y+1)*width], max); err != nil {
+			return nil, err
+		}
+	}
+	return pix, nil
+}
 
-			// Lire la composante bleue
-			scanner.Scan()
-			blue, err := strconv.ParseUint(scanner.Text(), 10, 8)
+// readPlainRow lit une ligne de pixels d'une image P3.
+func readPlainRow(reader *header.Reader, row []Pixel16, max int) error {
+	for x := range row {
+		var rgb [3]uint16
+		for i := range rgb {
+			val, err := reader.ReadSample(max)
 			if err != nil {
-				return nil, err
+				return err
 			}
-			pixel.B = uint8(blue)
+			rgb[i] = val
+		}
+		row[x] = Pixel16{R: rgb[0], G: rgb[1], B: rgb[2]}
+	}
+	return nil
+}
 
-			data[i][j] = pixel
+// readRaw lit les pixels d'une image P6, à raison de trois échantillons (R, G, B) par pixel.
+// Chaque échantillon occupe un octet, ou deux octets en gros-boutiste si la valeur maximale dépasse 255.
+func readRaw(reader *header.Reader, width, height, max int) ([]Pixel16, error) {
+	var pix []Pixel16
+	raw := make([]byte, 3*header.SampleSize(max)*width)
+	for y := 0; y < height; y++ {
+		pix = slices.Grow(pix, width)[:len(pix)+width]
+		if err := readRawRow(reader, raw, pix[y*width:(y+1)*width], max); err != nil {
+			return nil, err
 		}
 	}
+	return pix, nil
+}
 
-	return &PPM{
-		data:        data,
-		width:       width,
-		height:      height,
-		magicNumber: magicNumber,
-		max:         uint(max),
-	}, nil
+// readRawRow lit une ligne de pixels d'une image P6, en utilisant raw comme tampon.
+func readRawRow(reader *header.Reader, raw []byte, row []Pixel16, max int) error {
+	size := header.SampleSize(max)
+	if err := reader.ReadFull(raw, "pixels"); err != nil {
+		return err
+	}
+	var rgb [3]uint16
+	for x := range row {
+		for i := range rgb {
+			if size == 1 {
+				rgb[i] = uint16(raw[3*x+i])
+			} else {
+				rgb[i] = binary.BigEndian.Uint16(raw[2*(3*x+i):])
+			}
+			if int(rgb[i]) > max {
+				return sampleError(reader, rgb[i], max, len(raw)-size*(3*x+i))
+			}
+		}
+		row[x] = Pixel16{R: rgb[0], G: rgb[1], B: rgb[2]}
+	}
+	return nil
+}
+
+// sampleError signale un échantillon binaire supérieur à la valeur maximale,
+// situé back octets avant la position courante du lecteur.
+func sampleError(reader *header.Reader, value uint16, max int, back int) error {
+	err := reader.NewError("pixels", header.ErrInvalidValue, fmt.Sprintf("entier entre 0 et %d", max), fmt.Sprint(value))
+	err.Offset -= int64(back)
+	return err
 }
 
 // Size renvoie la largeur et la hauteur de l'image.
@@ -100,81 +180,268 @@ func (ppm *PPM) Size() (int, int) {
 }
 
 // At renvoie la valeur du pixel à la position (x, y).
+// Si la valeur maximale dépasse 255, les composantes sont ramenées dans la plage [0, 255] ;
+// At16 renvoie les valeurs exactes.
 func (ppm *PPM) At(x, y int) Pixel {
-	return ppm.data[y][x]
+	pixel, max := ppm.At16(x, y), int(ppm.max)
+	if max <= 255 {
+		return Pixel{R: uint8(pixel.R), G: uint8(pixel.G), B: uint8(pixel.B)}
+	}
+	return Pixel{
+		R: uint8(header.ScaleSample(pixel.R, max, 255)),
+		G: uint8(header.ScaleSample(pixel.G, max, 255)),
+		B: uint8(header.ScaleSample(pixel.B, max, 255)),
+	}
 }
 
 // Set définit la valeur du pixel à la position (x, y).
+// Si la valeur maximale dépasse 255, les composantes sont prises dans la plage [0, 255]
+// et converties vers la plage [0, MaxValue()] ; Set16 définit les valeurs exactes.
 func (ppm *PPM) Set(x, y int, value Pixel) {
-	ppm.data[y][x] = value
+	pixel, max := Pixel16{R: uint16(value.R), G: uint16(value.G), B: uint16(value.B)}, int(ppm.max)
+	if max > 255 {
+		pixel = Pixel16{R: header.ScaleSample(pixel.R, 255, max), G: header.ScaleSample(pixel.G, 255, max), B: header.ScaleSample(pixel.B, 255, max)}
+	}
+	ppm.Set16(x, y, pixel)
+}
+
+// At16 renvoie la valeur du pixel à la position (x, y), dans la plage [0, MaxValue()].
+func (ppm *PPM) At16(x, y int) Pixel16 {
+	return ppm.row(y)[x]
+}
+
+// Set16 définit la valeur du pixel à la position (x, y), dans la plage [0, MaxValue()].
+func (ppm *PPM) Set16(x, y int, value Pixel16) {
+	ppm.row(y)[x] = value
+}
+
+// row renvoie la ligne y de l'image, qui partage le tampon des pixels.
+func (ppm *PPM) row(y int) []Pixel16 {
+	return ppm.pix[y*ppm.stride : y*ppm.stride+ppm.width]
+}
+
+// Pix renvoie le tampon contigu des pixels, sans copie : le pixel (x, y) se trouve
+// à l'indice y*Stride() + x. Les modifications du tampon sont visibles dans l'image.
+func (ppm *PPM) Pix() []Pixel16 {
+	return ppm.pix
+}
+
+// Stride renvoie l'écart, en nombre de pixels, entre deux lignes consécutives de Pix.
+func (ppm *PPM) Stride() int {
+	return ppm.stride
 }
 
 // Save enregistre l'image PPM dans un fichier et renvoie une erreur en cas de problème.
+// Une erreur est renvoyée, sans créer le fichier, si Validate échoue.
+// Les pixels sont écrits en binaire si le numéro magique est P6, en texte sinon.
 func (ppm *PPM) Save(filename string) error {
+	if err := ppm.Validate(); err != nil {
+		return err
+	}
+
 	file, err := os.Create(filename)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	writer := bufio.NewWriter(file)
-	fmt.Fprintf(writer, "%s\n%d %d\n%d\n", ppm.magicNumber, ppm.width, ppm.height, ppm.max)
-	for _, row := range ppm.data {
-		for _, pixel := range row {
-			fmt.Fprintf(writer, "%d %d %d ", pixel.R, pixel.G, pixel.B)
-		}
-		fmt.Fprintln(writer)
+	if err := ppm.encode(file, header.DefaultWriteOptions); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Encode écrit l'image PPM dans w au format indiqué par son numéro magique.
+// Une erreur est renvoyée, sans rien écrire, si Validate échoue.
+// Les options header.DefaultWriteOptions sont appliquées.
+func (ppm *PPM) Encode(w io.Writer) error {
+	return ppm.EncodeWithOptions(w, header.DefaultWriteOptions)
+}
+
+// EncodeWithOptions écrit l'image PPM dans w, en mettant en forme les pixels selon
+// options si le numéro magique est P3.
+func (ppm *PPM) EncodeWithOptions(w io.Writer, options header.WriteOptions) error {
+	if err := options.Check(); err != nil {
+		return err
+	}
+	if err := ppm.Validate(); err != nil {
+		return err
+	}
+	return ppm.encode(w, options)
+}
+
+// encode écrit l'en-tête et les pixels de l'image PPM dans w.
+func (ppm *PPM) encode(w io.Writer, options header.WriteOptions) error {
+	writer := newTextWriter(w, options)
+	writeHeader(writer, ppm.magicNumber, ppm.comments, ppm.width, ppm.height, int(ppm.max))
+	for y := 0; y < ppm.height; y++ {
+		writeRow(writer, ppm.row(y), ppm.magicNumber, int(ppm.max))
 	}
 	return writer.Flush()
 }
 
+// newTextWriter renvoie un header.TextWriter qui écrit dans w selon options.
+// Un séparateur vide est remplacé par une espace, les échantillons devant être séparés.
+func newTextWriter(w io.Writer, options header.WriteOptions) *header.TextWriter {
+	if options.Separator == "" {
+		options.Separator = " "
+	}
+	return header.NewTextWriter(w, options)
+}
+
+// writeHeader écrit l'en-tête d'une image PPM.
+// Les commentaires sont écrits juste après le numéro magique.
+func writeHeader(writer *header.TextWriter, magicNumber string, comments []string, width, height, max int) {
+	fmt.Fprintf(writer, "%s\n", magicNumber)
+	header.WriteComments(writer, comments)
+	fmt.Fprintf(writer, "%d %d\n%d\n", width, height, max)
+}
+
+// writeRow écrit une ligne de pixels, en binaire si le numéro magique est P6, en texte sinon.
+// Les erreurs d'écriture sont conservées par writer et renvoyées par Flush.
+func writeRow(writer *header.TextWriter, row []Pixel16, magicNumber string, max int) {
+	size := header.SampleSize(max)
+	for _, pixel := range row {
+		if magicNumber == "P6" {
+			if size == 1 {
+				writer.Write([]byte{uint8(pixel.R), uint8(pixel.G), uint8(pixel.B)})
+			} else {
+				var raw [6]byte
+				binary.BigEndian.PutUint16(raw[0:], pixel.R)
+				binary.BigEndian.PutUint16(raw[2:], pixel.G)
+				binary.BigEndian.PutUint16(raw[4:], pixel.B)
+				writer.Write(raw[:])
+			}
+		} else {
+			writer.WriteValue(strconv.Itoa(int(pixel.R)))
+			writer.WriteValue(strconv.Itoa(int(pixel.G)))
+			writer.WriteValue(strconv.Itoa(int(pixel.B)))
+		}
+	}
+	if magicNumber != "P6" {
+		writer.EndLine()
+	}
+}
+
 // Invert inverse les couleurs de l'image PPM.
 func (ppm *PPM) Invert() {
-	for y := range ppm.data {
-		for x := range ppm.data[y] {
-			ppm.data[y][x].R = uint8(ppm.max) - ppm.data[y][x].R
-			ppm.data[y][x].G = uint8(ppm.max) - ppm.data[y][x].G
-			ppm.data[y][x].B = uint8(ppm.max) - ppm.data[y][x].B
-		}
+	for y := 0; y < ppm.height; y++ {
+		InvertRow(ppm.row(y), uint16(ppm.max))
 	}
 }
 
 // Flip retourne l'image PPM horizontalement.
 func (ppm *PPM) Flip() {
-	for y := range ppm.data {
-		for x := 0; x < ppm.width/2; x++ {
-			ppm.data[y][x], ppm.data[y][ppm.width-x-1] = ppm.data[y][ppm.width-x-1], ppm.data[y][x]
-		}
+	for y := 0; y < ppm.height; y++ {
+		FlipRow(ppm.row(y))
 	}
 }
 
 // Flop retourne l'image PPM verticalement.
 func (ppm *PPM) Flop() {
 	for y := 0; y < ppm.height/2; y++ {
-		ppm.data[y], ppm.data[ppm.height-y-1] = ppm.data[ppm.height-y-1], ppm.data[y]
+		top, bottom := ppm.row(y), ppm.row(ppm.height-y-1)
+		for x := range top {
+			top[x], bottom[x] = bottom[x], top[x]
+		}
 	}
 }
 
+// MagicNumber renvoie le numéro magique de l'image PPM.
+func (ppm *PPM) MagicNumber() string {
+	return ppm.magicNumber
+}
+
 // SetMagicNumber définit le numéro magique de l'image PPM.
 func (ppm *PPM) SetMagicNumber(magicNumber string) {
 	ppm.magicNumber = magicNumber
 }
 
-// SetMaxValue définit la valeur maximale de l'image PPM.
+// Comments renvoie une copie des commentaires de l'en-tête, sans le # initial.
+func (ppm *PPM) Comments() []string {
+	return slices.Clone(ppm.comments)
+}
+
+// SetComments définit les commentaires écrits dans l'en-tête par Save et Encode.
+// Un commentaire contenant des sauts de ligne est écrit sur plusieurs lignes.
+func (ppm *PPM) SetComments(comments []string) {
+	ppm.comments = slices.Clone(comments)
+}
+
+// MaxValue renvoie la valeur maximale de l'image PPM.
+func (ppm *PPM) MaxValue() uint16 {
+	return uint16(ppm.max)
+}
+
+// SetMaxValue définit la valeur maximale de l'image PPM sans modifier les pixels.
+// Utilisez ScaleMaxValue pour convertir les pixels vers la nouvelle plage.
 func (ppm *PPM) SetMaxValue(maxValue uint8) {
 	ppm.max = uint(maxValue)
 }
 
+// SetMaxValue16 fonctionne comme SetMaxValue pour une valeur maximale allant jusqu'à 65535.
+func (ppm *PPM) SetMaxValue16(maxValue uint16) {
+	ppm.max = uint(maxValue)
+}
+
+// ScaleMaxValue définit la valeur maximale de l'image PPM et convertit chaque composante
+// proportionnellement vers la nouvelle plage, en arrondissant au plus proche.
+// Une composante au-delà de l'ancienne valeur maximale devient la nouvelle valeur maximale.
+// Une valeur maximale nulle est refusée et l'image reste inchangée.
+func (ppm *PPM) ScaleMaxValue(maxValue uint16) error {
+	if maxValue == 0 {
+		return fmt.Errorf("valeur maximale non valide : %d", maxValue)
+	}
+	from, to := int(ppm.max), int(maxValue)
+	for y := 0; y < ppm.height; y++ {
+		row := ppm.row(y)
+		for x := range row {
+			pixel := &row[x]
+			pixel.R = header.ScaleSample(pixel.R, from, to)
+			pixel.G = header.ScaleSample(pixel.G, from, to)
+			pixel.B = header.ScaleSample(pixel.B, from, to)
+		}
+	}
+	ppm.max = uint(maxValue)
+	return nil
+}
+
+// Validate vérifie la cohérence de l'image : numéro magique P3 ou P6, dimensions
+// positives, tampon de pixels assez grand pour le pas annoncé, valeur maximale comprise
+// entre 1 et 65535 et aucune composante au-delà de celle-ci.
+func (ppm *PPM) Validate() error {
+	if ppm.magicNumber != "P3" && ppm.magicNumber != "P6" {
+		return fmt.Errorf("numéro magique non valide : %q", ppm.magicNumber)
+	}
+	if ppm.width < 0 || ppm.height < 0 {
+		return fmt.Errorf("dimensions non valides : %d × %d", ppm.width, ppm.height)
+	}
+	if ppm.height > 0 && (ppm.stride < ppm.width || len(ppm.pix) < (ppm.height-1)*ppm.stride+ppm.width) {
+		return fmt.Errorf("%d pixels avec un pas de %d pour une image de %d × %d", len(ppm.pix), ppm.stride, ppm.width, ppm.height)
+	}
+	if ppm.max < 1 || ppm.max > 65535 {
+		return fmt.Errorf("valeur maximale non valide : %d", ppm.max)
+	}
+	max := uint16(ppm.max)
+	for y := 0; y < ppm.height; y++ {
+		for x, pixel := range ppm.row(y) {
+			if pixel.R > max || pixel.G > max || pixel.B > max {
+				return fmt.Errorf("le pixel (%d, %d) vaut %v, au-delà de la valeur maximale %d", x, y, pixel, max)
+			}
+		}
+	}
+	return nil
+}
+
 // Rotate90CW fait pivoter l'image PPM de 90° dans le sens des aiguilles d'une montre.
 func (ppm *PPM) Rotate90CW() {
-	newData := make([][]Pixel, ppm.width)
-	for i := range newData {
-		newData[i] = make([]Pixel, ppm.height)
-		for j := range newData[i] {
-			newData[i][j] = ppm.data[ppm.height-j-1][i]
+	pix := make([]Pixel16, ppm.width*ppm.height)
+	for i := 0; i < ppm.width; i++ {
+		for j := 0; j < ppm.height; j++ {
+			pix[i*ppm.height+j] = ppm.At16(i, ppm.height-j-1)
 		}
 	}
-	ppm.data = newData
+	ppm.pix, ppm.stride = pix, ppm.height
 	ppm.width, ppm.height = ppm.height, ppm.width
 }
 
@@ -343,13 +610,9 @@ func (ppm *PPM) DrawFilledPolygon(points []Point, color Pixel) {
 
 // NewPPM crée une nouvelle image PPM avec la largeur et la hauteur spécifiées.
 func NewPPM(width, height int) *PPM {
-	data := make([][]Pixel, height)
-	for i := range data {
-		data[i] = make([]Pixel, width)
-	}
-
 	return &PPM{
-		data:        data,
+		pix:         make([]Pixel16, width*height),
+		stride:      width,
 		width:       width,
 		height:      height,
 		magicNumber: "P3",
@@ -0,0 +1,162 @@
+package ppm
+
+import (
+	"Netpbm/internal/imagetest"
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// randomPPM renvoie une image aléatoire de valeur maximale max.
+func randomPPM(rng *rand.Rand, width, height int, max uint16) *PPM {
+	ppm := NewPPM(width, height)
+	ppm.SetMaxValue16(max)
+	sample := func() uint16 { return uint16(rng.Intn(int(max) + 1)) }
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			ppm.Set16(x, y, Pixel16{R: sample(), G: sample(), B: sample()})
+		}
+	}
+	// Les valeurs extrêmes sont toujours présentes
+	if width > 1 && height > 0 {
+		ppm.Set16(0, 0, Pixel16{})
+		ppm.Set16(width-1, height-1, Pixel16{R: max, G: max, B: max})
+	}
+	return ppm
+}
+
+func TestRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, magicNumber := range []string{"P3", "P6"} {
+		for _, max := range []uint16{255, 4095, 65535} {
+			for _, width := range []int{1, 7, 13, 100} {
+				ppm := randomPPM(rng, width, 3, max)
+				ppm.SetMagicNumber(magicNumber)
+				imagetest.RoundTrip(t, ppm, Decode)
+			}
+		}
+	}
+}
+
+func TestEncodeRaw(t *testing.T) {
+	tests := []struct {
+		max    uint16
+		pixels []Pixel16
+		want   string
+	}{
+		{255, []Pixel16{{1, 2, 3}, {255, 0, 10}}, "P6\n2 1\n255\n\x01\x02\x03\xff\x00\x0a"},
+		// Au-delà de 255, deux octets par échantillon, l'octet de poids fort en premier
+		{4095, []Pixel16{{1, 4095, 256}}, "P6\n1 1\n4095\n\x00\x01\x0f\xff\x01\x00"},
+	}
+	for _, test := range tests {
+		ppm := NewPPM(len(test.pixels), 1)
+		ppm.SetMagicNumber("P6")
+		ppm.SetMaxValue16(test.max)
+		for x, pixel := range test.pixels {
+			ppm.Set16(x, 0, pixel)
+		}
+		var buf bytes.Buffer
+		if err := ppm.Encode(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != test.want {
+			t.Errorf("maxval %d : %q, %q attendu", test.max, buf.String(), test.want)
+		}
+	}
+}
+
+func TestDecodeRawWhitespaceSamples(t *testing.T) {
+	// Un seul caractère d'espacement sépare la valeur maximale des pixels : les octets
+	// suivants sont des pixels, même s'ils ressemblent à des espaces ou à un commentaire
+	tests := []struct {
+		input string
+		want  Pixel16
+	}{
+		{"P6 1 1 255\n\n \t", Pixel16{'\n', ' ', '\t'}},
+		{"P6 1 1 255 #\r\n", Pixel16{'#', '\r', '\n'}},
+		{"P6 1 1 4095\n\x0a\x20\x00\x0d\x00\x09", Pixel16{0x0a20, 0x000d, 0x0009}},
+	}
+	for _, test := range tests {
+		ppm, err := Decode(bytes.NewReader([]byte(test.input)))
+		if err != nil {
+			t.Fatalf("%q : %v", test.input, err)
+		}
+		if got := ppm.At16(0, 0); got != test.want {
+			t.Errorf("%q : pixel %v, %v attendu", test.input, got, test.want)
+		}
+	}
+}
+
+func TestEightBitAccessors(t *testing.T) {
+	ppm := NewPPM(2, 1)
+	var max, red uint8 = 200, 150
+	ppm.SetMaxValue(max)
+	ppm.Set(0, 0, Pixel{R: red, G: 1, B: 2})
+	if got := ppm.At(0, 0); got != (Pixel{R: 150, G: 1, B: 2}) || ppm.At16(0, 0) != (Pixel16{R: 150, G: 1, B: 2}) {
+		t.Errorf("maxval 200 : At %v, At16 %v, {150 1 2} attendu", got, ppm.At16(0, 0))
+	}
+
+	// Au-delà de 255, les accesseurs 8 bits travaillent dans la plage [0, 255]
+	ppm.SetMaxValue16(4095)
+	ppm.Set(1, 0, Pixel{R: 255, G: 0, B: 128})
+	if got := ppm.At16(1, 0); got != (Pixel16{R: 4095, G: 0, B: 2056}) {
+		t.Errorf("maxval 4095 : Set({255 0 128}) donne %v, {4095 0 2056} attendu", got)
+	}
+	ppm.Set16(0, 0, Pixel16{R: 2048, G: 4095, B: 0})
+	if got := ppm.At(0, 0); got != (Pixel{R: 128, G: 255, B: 0}) {
+		t.Errorf("maxval 4095 : At %v, {128 255 0} attendu", got)
+	}
+}
+
+func TestScaleMaxValue(t *testing.T) {
+	ppm := NewPPM(2, 1)
+	ppm.SetMaxValue(255)
+	ppm.Set16(0, 0, Pixel16{R: 8, G: 9, B: 255})
+	ppm.Set16(1, 0, Pixel16{R: 128, G: 0, B: 17})
+	if err := ppm.ScaleMaxValue(15); err != nil {
+		t.Fatal(err)
+	}
+	// 8 × 15 / 255 ≈ 0,47 et 9 × 15 / 255 ≈ 0,53 : arrondi au plus proche
+	for x, want := range []Pixel16{{R: 0, G: 1, B: 15}, {R: 8, G: 0, B: 1}} {
+		if got := ppm.At16(x, 0); got != want {
+			t.Errorf("255 → 15 : pixel %d vaut %v, %v attendu", x, got, want)
+		}
+	}
+
+	// Une composante au-delà de la valeur maximale est ramenée à la nouvelle valeur maximale
+	ppm.SetMaxValue(1)
+	ppm.Set16(0, 0, Pixel16{R: 65535, G: 1, B: 0})
+	if err := ppm.ScaleMaxValue(65535); err != nil {
+		t.Fatal(err)
+	}
+	if got := ppm.At16(0, 0); got != (Pixel16{R: 65535, G: 65535, B: 0}) {
+		t.Errorf("1 → 65535 : pixel %v, {65535 65535 0} attendu", got)
+	}
+
+	if err := ppm.ScaleMaxValue(0); err == nil || ppm.MaxValue() != 65535 {
+		t.Errorf("ScaleMaxValue(0) : erreur %v, valeur maximale %d", err, ppm.MaxValue())
+	}
+}
+
+func TestRefuseInvalid(t *testing.T) {
+	badMagic := NewPPM(2, 1)
+	badMagic.SetMagicNumber("P5")
+	overMax := NewPPM(2, 1)
+	overMax.SetMaxValue(15)
+	overMax.Set16(1, 0, Pixel16{R: 1, G: 16, B: 2})
+	for name, ppm := range map[string]*PPM{"nombre magique": badMagic, "valeur trop grande": overMax} {
+		filename := filepath.Join(t.TempDir(), "image.ppm")
+		if err := ppm.Save(filename); err == nil {
+			t.Errorf("%s : Save accepte une image non valide", name)
+		}
+		if _, err := os.Stat(filename); !os.IsNotExist(err) {
+			t.Errorf("%s : Save a créé le fichier (%v)", name, err)
+		}
+		var buf bytes.Buffer
+		if err := ppm.Encode(&buf); err == nil || buf.Len() > 0 {
+			t.Errorf("%s : Encode écrit %d octets, erreur %v", name, buf.Len(), err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package ppm
+
+import (
+	"Netpbm/header"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// RowReader lit une image PPM ligne par ligne, sans jamais conserver plus d'une ligne
+// en mémoire, ce qui permet de traiter des images de taille arbitraire.
+type RowReader struct {
+	reader        *header.Reader
+	width, height int
+	magicNumber   string
+	max           int
+	y             int
+	raw           []byte
+}
+
+// NewRowReader lit l'en-tête de l'image PPM contenue dans r et renvoie un RowReader
+// positionné sur la première ligne. Les limites header.DefaultRowLimits, qui ne bornent
+// que la taille d'une ligne, sont appliquées.
+func NewRowReader(r io.Reader) (*RowReader, error) {
+	return NewRowReaderWithLimits(r, header.DefaultRowLimits)
+}
+
+// NewRowReaderWithLimits lit l'en-tête de l'image PPM contenue dans r en appliquant limits.
+func NewRowReaderWithLimits(r io.Reader, limits header.Limits) (*RowReader, error) {
+	reader := header.NewReader(r)
+	ppm, err := readHeader(reader, limits)
+	if err != nil {
+		return nil, err
+	}
+	rr := &RowReader{
+		reader:      reader,
+		width:       ppm.width,
+		height:      ppm.height,
+		magicNumber: ppm.magicNumber,
+		max:         int(ppm.max),
+	}
+	if rr.magicNumber == "P6" {
+		rr.raw = make([]byte, 3*header.SampleSize(rr.max)*rr.width)
+	}
+	return rr, nil
+}
+
+// Size renvoie la largeur et la hauteur de l'image.
+func (rr *RowReader) Size() (int, int) {
+	return rr.width, rr.height
+}
+
+// MagicNumber renvoie le numéro magique de l'image.
+func (rr *RowReader) MagicNumber() string {
+	return rr.magicNumber
+}
+
+// MaxValue renvoie la valeur maximale de l'image.
+func (rr *RowReader) MaxValue() uint16 {
+	return uint16(rr.max)
+}
+
+// ReadRow lit la ligne suivante dans row, qui doit contenir exactement une ligne de pixels.
+// io.EOF est renvoyé une fois toutes les lignes lues.
+func (rr *RowReader) ReadRow(row []Pixel16) error {
+	if rr.y >= rr.height {
+		return io.EOF
+	}
+	if len(row) != rr.width {
+		return fmt.Errorf("ligne de %d pixels, %d attendus", len(row), rr.width)
+	}
+	var err error
+	if rr.magicNumber == "P3" {
+		err = readPlainRow(rr.reader, row, rr.max)
+	} else {
+		err = readRawRow(rr.reader, rr.raw, row, rr.max)
+	}
+	if err != nil {
+		return err
+	}
+	rr.y++
+	return nil
+}
+
+// RowWriter écrit une image PPM ligne par ligne.
+type RowWriter struct {
+	writer        *header.TextWriter
+	width, height int
+	magicNumber   string
+	max           int
+	y             int
+}
+
+// NewRowWriter écrit l'en-tête d'une image PPM dans w et renvoie un RowWriter
+// qui attend ensuite height lignes de width pixels.
+// Les options header.DefaultWriteOptions sont appliquées.
+func NewRowWriter(w io.Writer, width, height int, magicNumber string, max uint16) (*RowWriter, error) {
+	return NewRowWriterWithOptions(w, width, height, magicNumber, max, header.DefaultWriteOptions)
+}
+
+// NewRowWriterWithOptions fonctionne comme NewRowWriter, en mettant en forme les pixels
+// selon options si le numéro magique est P3.
+func NewRowWriterWithOptions(w io.Writer, width, height int, magicNumber string, max uint16, options header.WriteOptions) (*RowWriter, error) {
+	if magicNumber != "P3" && magicNumber != "P6" {
+		return nil, fmt.Errorf("numéro magique non valide : %q", magicNumber)
+	}
+	if width < 0 || height < 0 || max == 0 {
+		return nil, fmt.Errorf("dimensions ou valeur maximale non valides : %d × %d, %d", width, height, max)
+	}
+	if err := options.Check(); err != nil {
+		return nil, err
+	}
+	rw := &RowWriter{
+		writer:      newTextWriter(w, options),
+		width:       width,
+		height:      height,
+		magicNumber: magicNumber,
+		max:         int(max),
+	}
+	writeHeader(rw.writer, magicNumber, nil, width, height, rw.max)
+	return rw, nil
+}
+
+// WriteRow écrit la ligne suivante de l'image.
+func (rw *RowWriter) WriteRow(row []Pixel16) error {
+	if rw.y >= rw.height {
+		return errors.New("toutes les lignes de l'image ont déjà été écrites")
+	}
+	if len(row) != rw.width {
+		return fmt.Errorf("ligne de %d pixels, %d attendus", len(row), rw.width)
+	}
+	max := uint16(rw.max)
+	for x, pixel := range row {
+		if pixel.R > max || pixel.G > max || pixel.B > max {
+			return fmt.Errorf("le pixel (%d, %d) vaut %v, au-delà de la valeur maximale %d", x, rw.y, pixel, max)
+		}
+	}
+	writeRow(rw.writer, row, rw.magicNumber, rw.max)
+	rw.y++
+	return nil
+}
+
+// Close vide le tampon d'écriture et vérifie que toutes les lignes ont été écrites.
+// Le io.Writer sous-jacent n'est pas fermé.
+func (rw *RowWriter) Close() error {
+	if err := rw.writer.Flush(); err != nil {
+		return err
+	}
+	if rw.y < rw.height {
+		return fmt.Errorf("%d lignes écrites sur %d", rw.y, rw.height)
+	}
+	return nil
+}
+
+// InvertRow inverse les couleurs d'une ligne de pixels dont la valeur maximale est max.
+func InvertRow(row []Pixel16, max uint16) {
+	for x := range row {
+		row[x].R = max - row[x].R
+		row[x].G = max - row[x].G
+		row[x].B = max - row[x].B
+	}
+}
+
+// FlipRow retourne une ligne de pixels horizontalement.
+func FlipRow(row []Pixel16) {
+	for x := 0; x < len(row)/2; x++ {
+		row[x], row[len(row)-x-1] = row[len(row)-x-1], row[x]
+	}
+}
@@ -0,0 +1,53 @@
+package ppm
+
+import (
+	"Netpbm/internal/imagetest"
+	"bytes"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestReaderNext(t *testing.T) {
+	// Une image P6 dont les derniers octets ressemblent à des espaces, puis une image P3
+	// et un commentaire final
+	input := "P6 1 1 255\n\r\n\t" + "P3 1 1 15 1 2 3\n# fin du flux\n"
+	r := NewReader(strings.NewReader(input))
+	for i, want := range []Pixel16{{'\r', '\n', '\t'}, {1, 2, 3}} {
+		ppm, err := r.Next()
+		if err != nil {
+			t.Fatalf("image %d : %v", i, err)
+		}
+		if got := ppm.At16(0, 0); got != want {
+			t.Errorf("image %d : pixel %v, %v attendu", i, got, want)
+		}
+	}
+	if ppm, err := r.Next(); err != io.EOF {
+		t.Fatalf("après la dernière image : %v, %v, io.EOF attendu", ppm, err)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	images := []*PPM{randomPPM(rng, 4, 2, 255), randomPPM(rng, 3, 3, 65535), randomPPM(rng, 5, 1, 15)}
+	images[1].SetMagicNumber("P6")
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, ppm := range images {
+		if err := w.Write(ppm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := NewReader(&buf)
+	for _, want := range images {
+		got, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		imagetest.Equal(t, got, want)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("%v, io.EOF attendu", err)
+	}
+}
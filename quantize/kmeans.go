@@ -0,0 +1,56 @@
+package quantize
+
+import (
+	"Netpbm/ppm"
+	"math"
+)
+
+// KMeans choisit la palette par l'algorithme des k-moyennes : partant de la palette de la
+// coupe médiane, chaque couleur est rattachée au centre le plus proche, puis chaque centre
+// est remplacé par la moyenne de ses couleurs, jusqu'à stabilité ou épuisement des itérations.
+type KMeans struct {
+	// Iterations borne le nombre d'itérations, 10 s'il est nul.
+	Iterations int
+}
+
+// Palette renvoie une palette d'au plus colors couleurs représentative de src.
+func (k KMeans) Palette(src *ppm.PPM, colors int) Palette {
+	hist := histogram(src)
+	centers := medianCut(hist, colors)
+	iterations := k.Iterations
+	if iterations == 0 {
+		iterations = 10
+	}
+
+	assignment := make([]int, len(hist))
+	sums := make([][4]float64, len(centers))
+	for iteration := 0; iteration < iterations; iteration++ {
+		changed := iteration == 0
+		clear(sums)
+		for i, c := range hist {
+			index := nearest(centers, float64(c.pixel.R), float64(c.pixel.G), float64(c.pixel.B))
+			if index != assignment[i] {
+				assignment[i], changed = index, true
+			}
+			weight := float64(c.count)
+			sums[index][0] += weight * float64(c.pixel.R)
+			sums[index][1] += weight * float64(c.pixel.G)
+			sums[index][2] += weight * float64(c.pixel.B)
+			sums[index][3] += weight
+		}
+		if !changed {
+			break
+		}
+		// Un centre sans couleur rattachée reste en place
+		for i, sum := range sums {
+			if n := sum[3]; n > 0 {
+				centers[i] = ppm.Pixel16{
+					R: uint16(math.Round(sum[0] / n)),
+					G: uint16(math.Round(sum[1] / n)),
+					B: uint16(math.Round(sum[2] / n)),
+				}
+			}
+		}
+	}
+	return centers
+}
@@ -0,0 +1,87 @@
+package quantize
+
+import (
+	"Netpbm/ppm"
+	"cmp"
+	"slices"
+)
+
+// MedianCut choisit la palette par la coupe médiane de Heckbert : l'ensemble des couleurs
+// est découpé, selon la composante la plus étendue, en boîtes contenant chacune la moitié
+// des pixels de la boîte d'origine. Chaque boîte donne sa couleur moyenne.
+type MedianCut struct{}
+
+// Palette renvoie une palette d'au plus colors couleurs représentative de src.
+func (MedianCut) Palette(src *ppm.PPM, colors int) Palette {
+	return medianCut(histogram(src), colors)
+}
+
+// medianCut découpe les couleurs de hist en au plus colors boîtes et renvoie leurs moyennes.
+func medianCut(hist []colorCount, colors int) Palette {
+	if len(hist) == 0 {
+		return nil
+	}
+	boxes := [][]colorCount{hist}
+	for len(boxes) < colors {
+		// Découper la boîte dont une composante est la plus étendue
+		best, bestRange, channel := -1, 0, 0
+		for i, box := range boxes {
+			if c, r := widestChannel(box); r > bestRange {
+				best, bestRange, channel = i, r, c
+			}
+		}
+		if best < 0 {
+			break
+		}
+		box := boxes[best]
+		slices.SortStableFunc(box, func(a, b colorCount) int {
+			return cmp.Compare(component(a.pixel, channel), component(b.pixel, channel))
+		})
+
+		// Couper à la médiane pondérée, sans laisser de boîte vide
+		total := 0
+		for _, c := range box {
+			total += c.count
+		}
+		cut, seen := 1, box[0].count
+		for cut < len(box)-1 && 2*seen < total {
+			seen += box[cut].count
+			cut++
+		}
+		boxes[best] = box[:cut]
+		boxes = append(boxes, box[cut:])
+	}
+
+	palette := make(Palette, len(boxes))
+	for i, box := range boxes {
+		palette[i] = average(box)
+	}
+	return palette
+}
+
+// widestChannel renvoie la composante (0 pour le rouge, 1 pour le vert, 2 pour le bleu)
+// dont les valeurs sont les plus étendues dans box, et cette étendue.
+func widestChannel(box []colorCount) (channel, extent int) {
+	for c := 0; c < 3; c++ {
+		low, high := component(box[0].pixel, c), component(box[0].pixel, c)
+		for _, color := range box[1:] {
+			v := component(color.pixel, c)
+			low, high = min(low, v), max(high, v)
+		}
+		if int(high-low) > extent {
+			channel, extent = c, int(high-low)
+		}
+	}
+	return channel, extent
+}
+
+// component renvoie la composante c d'un pixel : 0 pour le rouge, 1 pour le vert, 2 pour le bleu.
+func component(pixel ppm.Pixel16, c int) uint16 {
+	switch c {
+	case 0:
+		return pixel.R
+	case 1:
+		return pixel.G
+	}
+	return pixel.B
+}
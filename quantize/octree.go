@@ -0,0 +1,120 @@
+package quantize
+
+import (
+	"Netpbm/ppm"
+	"math"
+	"slices"
+)
+
+// octreeDepth est le nombre de niveaux de l'octree, soit un par bit d'une composante sur 8 bits.
+const octreeDepth = 8
+
+// Octree choisit la palette par la quantification en octree de Gervautz et Purgathofer :
+// les couleurs sont rangées dans un arbre dont chaque niveau divise le cube RGB en huit,
+// puis les nœuds les moins peuplés des niveaux les plus profonds sont fusionnés jusqu'à
+// ne plus dépasser le nombre de couleurs voulu. Chaque feuille donne sa couleur moyenne.
+type Octree struct{}
+
+// octreeNode est un nœud de l'octree. count est le nombre de pixels du sous-arbre ;
+// r, g et b sont les sommes de leurs composantes, tenues à jour dans les feuilles.
+type octreeNode struct {
+	children [8]*octreeNode
+	leaf     bool
+	count    int
+	r, g, b  float64
+}
+
+// Palette renvoie une palette d'au plus colors couleurs représentative de src.
+func (Octree) Palette(src *ppm.PPM, colors int) Palette {
+	max := int(src.MaxValue())
+	if max < 1 {
+		return nil
+	}
+
+	// Construire l'arbre en retenant les nœuds internes de chaque niveau
+	root := &octreeNode{}
+	var levels [octreeDepth][]*octreeNode
+	levels[0] = []*octreeNode{root}
+	leaves := 0
+	for _, c := range histogram(src) {
+		r, g, b := to8Bits(c.pixel.R, max), to8Bits(c.pixel.G, max), to8Bits(c.pixel.B, max)
+		node := root
+		for level := 0; level < octreeDepth; level++ {
+			node.count += c.count
+			shift := octreeDepth - 1 - level
+			i := (r>>shift&1)<<2 | (g>>shift&1)<<1 | b>>shift&1
+			if node.children[i] == nil {
+				child := &octreeNode{}
+				if level+1 < octreeDepth {
+					levels[level+1] = append(levels[level+1], child)
+				} else {
+					child.leaf = true
+					leaves++
+				}
+				node.children[i] = child
+			}
+			node = node.children[i]
+		}
+		node.count += c.count
+		node.r += float64(c.count) * float64(c.pixel.R)
+		node.g += float64(c.count) * float64(c.pixel.G)
+		node.b += float64(c.count) * float64(c.pixel.B)
+	}
+
+	// Fusionner les nœuds les moins peuplés, en commençant par le niveau le plus profond
+	for level := octreeDepth - 1; level >= 0 && leaves > colors; level-- {
+		nodes := levels[level]
+		slices.SortStableFunc(nodes, func(a, b *octreeNode) int { return a.count - b.count })
+		for _, node := range nodes {
+			if leaves <= colors {
+				break
+			}
+			leaves -= node.merge() - 1
+		}
+	}
+
+	var palette Palette
+	root.collect(&palette)
+	return palette
+}
+
+// merge fusionne les feuilles filles du nœud, qui devient une feuille,
+// et renvoie le nombre de feuilles fusionnées.
+func (node *octreeNode) merge() int {
+	merged := 0
+	for i, child := range node.children {
+		if child == nil {
+			continue
+		}
+		node.r += child.r
+		node.g += child.g
+		node.b += child.b
+		node.children[i] = nil
+		merged++
+	}
+	node.leaf = true
+	return merged
+}
+
+// collect ajoute à palette la couleur moyenne de chaque feuille du sous-arbre.
+func (node *octreeNode) collect(palette *Palette) {
+	if node.leaf {
+		n := float64(node.count)
+		*palette = append(*palette, ppm.Pixel16{
+			R: uint16(math.Round(node.r / n)),
+			G: uint16(math.Round(node.g / n)),
+			B: uint16(math.Round(node.b / n)),
+		})
+		return
+	}
+	for _, child := range node.children {
+		if child != nil {
+			child.collect(palette)
+		}
+	}
+}
+
+// to8Bits ramène une composante de la plage [0, max] à la plage [0, 255].
+func to8Bits(value uint16, max int) int {
+	return (int(value)*255 + max/2) / max
+}
@@ -0,0 +1,265 @@
+package quantize
+
+import (
+	"Netpbm/dither"
+	"Netpbm/ppm"
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Palette est une liste de couleurs exprimées dans la plage [0, max] de l'image quantifiée.
+type Palette []ppm.Pixel16
+
+// Quantizer choisit une palette d'au plus colors couleurs représentative d'une image PPM.
+type Quantizer interface {
+	Palette(src *ppm.PPM, colors int) Palette
+}
+
+// Options règle le remappage d'une image sur une palette.
+type Options struct {
+	// Dither diffuse l'erreur de chaque pixel vers ses voisins selon ce noyau, par exemple
+	// &dither.FloydSteinberg. Sans noyau, chaque pixel prend la couleur la plus proche.
+	Dither *dither.Kernel
+	// Serpentine parcourt les lignes alternativement dans les deux sens lors de la diffusion.
+	Serpentine bool
+}
+
+// Indexed représente une image dont chaque pixel est l'indice d'une couleur de sa palette.
+type Indexed struct {
+	pix           []int
+	width, height int
+	palette       Palette
+	max           uint16
+	magicNumber   string
+	comments      []string
+}
+
+// Size renvoie la largeur et la hauteur de l'image.
+func (img *Indexed) Size() (int, int) {
+	return img.width, img.height
+}
+
+// Palette renvoie une copie de la palette de l'image.
+func (img *Indexed) Palette() Palette {
+	return slices.Clone(img.palette)
+}
+
+// MaxValue renvoie la valeur maximale des composantes de la palette.
+func (img *Indexed) MaxValue() uint16 {
+	return img.max
+}
+
+// At renvoie l'indice dans la palette du pixel en (x, y).
+func (img *Indexed) At(x, y int) int {
+	if x < 0 || x >= img.width {
+		panic(fmt.Sprintf("quantize: abscisse %d hors de l'image", x))
+	}
+	return img.pix[y*img.width+x]
+}
+
+// Pix renvoie les indices des pixels, sans copie : le pixel (x, y) se trouve à l'indice
+// y*largeur + x.
+func (img *Indexed) Pix() []int {
+	return img.pix
+}
+
+// PPM renvoie l'image PPM obtenue en remplaçant chaque indice par sa couleur. L'image
+// reprend la valeur maximale, le numéro magique et les commentaires de l'image source.
+func (img *Indexed) PPM() *ppm.PPM {
+	dst := ppm.NewPPM(img.width, img.height)
+	dst.SetMaxValue16(img.max)
+	dst.SetMagicNumber(img.magicNumber)
+	dst.SetComments(img.comments)
+	pix, stride := dst.Pix(), dst.Stride()
+	for y := 0; y < img.height; y++ {
+		for x := 0; x < img.width; x++ {
+			pix[y*stride+x] = img.palette[img.pix[y*img.width+x]]
+		}
+	}
+	return dst
+}
+
+// Quantize réduit src à au plus colors couleurs choisies par quantizer, puis remappe
+// chaque pixel sur la palette obtenue selon options. Une image sans pixel donne une
+// image indexée vide, dont la palette est vide.
+func Quantize(src *ppm.PPM, quantizer Quantizer, colors int, options Options) (*Indexed, error) {
+	if colors < 1 {
+		return nil, fmt.Errorf("nombre de couleurs non valide : %d", colors)
+	}
+	if width, height := src.Size(); width == 0 || height == 0 {
+		return &Indexed{
+			width:       width,
+			height:      height,
+			max:         src.MaxValue(),
+			magicNumber: src.MagicNumber(),
+			comments:    src.Comments(),
+		}, nil
+	}
+	return Remap(src, quantizer.Palette(src, colors), options)
+}
+
+// Remap associe chaque pixel de src à une couleur de palette, par exemple une palette
+// fixe fournie par l'appelant, exprimée dans la plage [0, MaxValue()] de src.
+func Remap(src *ppm.PPM, palette Palette, options Options) (*Indexed, error) {
+	if len(palette) == 0 {
+		return nil, errors.New("palette vide")
+	}
+	max := src.MaxValue()
+	for i, color := range palette {
+		if color.R > max || color.G > max || color.B > max {
+			return nil, fmt.Errorf("la couleur %d de la palette vaut %v, au-delà de la valeur maximale %d", i, color, max)
+		}
+	}
+	if options.Dither != nil && options.Dither.Divisor == 0 {
+		return nil, errors.New("noyau de diffusion de diviseur nul")
+	}
+
+	width, height := src.Size()
+	dst := &Indexed{
+		pix:         make([]int, width*height),
+		width:       width,
+		height:      height,
+		palette:     slices.Clone(palette),
+		max:         max,
+		magicNumber: src.MagicNumber(),
+		comments:    src.Comments(),
+	}
+	if options.Dither != nil {
+		remapDiffuse(dst, src, *options.Dither, options.Serpentine)
+		return dst, nil
+	}
+
+	// Les couleurs déjà rencontrées ne sont cherchées qu'une fois
+	cache := make(map[ppm.Pixel16]int)
+	pix, stride := src.Pix(), src.Stride()
+	for y := 0; y < height; y++ {
+		for x, pixel := range pix[y*stride : y*stride+width] {
+			index, ok := cache[pixel]
+			if !ok {
+				index = nearest(palette, float64(pixel.R), float64(pixel.G), float64(pixel.B))
+				cache[pixel] = index
+			}
+			dst.pix[y*width+x] = index
+		}
+	}
+	return dst, nil
+}
+
+// remapDiffuse remappe src sur la palette de dst en diffusant l'erreur de chaque pixel,
+// composante par composante, vers ses voisins selon kernel.
+func remapDiffuse(dst *Indexed, src *ppm.PPM, kernel dither.Kernel, serpentine bool) {
+	width, height := dst.width, dst.height
+	if width == 0 {
+		return
+	}
+	rows := 1
+	for _, w := range kernel.Weights {
+		if w.DY+1 > rows {
+			rows = w.DY + 1
+		}
+	}
+	// Trois erreurs (rouge, vert, bleu) par pixel pour chacune des lignes à venir
+	errs := make([][]float64, rows)
+	for i := range errs {
+		errs[i] = make([]float64, 3*width)
+	}
+
+	max := float64(dst.max)
+	pix, stride := src.Pix(), src.Stride()
+	for y := 0; y < height; y++ {
+		current := errs[y%rows]
+		x, step, last := 0, 1, width
+		if serpentine && y%2 == 1 {
+			x, step, last = width-1, -1, -1
+		}
+		for ; x != last; x += step {
+			pixel := pix[y*stride+x]
+			r := clamp(float64(pixel.R)+current[3*x], max)
+			g := clamp(float64(pixel.G)+current[3*x+1], max)
+			b := clamp(float64(pixel.B)+current[3*x+2], max)
+			index := nearest(dst.palette, r, g, b)
+			dst.pix[y*width+x] = index
+			color := dst.palette[index]
+			dr, dg, db := r-float64(color.R), g-float64(color.G), b-float64(color.B)
+			for _, w := range kernel.Weights {
+				nx, ny := x+w.DX*step, y+w.DY
+				if nx < 0 || nx >= width || ny >= height {
+					continue
+				}
+				share := float64(w.Value) / float64(kernel.Divisor)
+				next := errs[ny%rows]
+				next[3*nx] += dr * share
+				next[3*nx+1] += dg * share
+				next[3*nx+2] += db * share
+			}
+		}
+		clear(current)
+	}
+}
+
+// clamp borne value à l'intervalle [0, max].
+func clamp(value, max float64) float64 {
+	return math.Max(0, math.Min(value, max))
+}
+
+// nearest renvoie l'indice de la couleur de palette la plus proche de (r, g, b)
+// au sens de la distance euclidienne.
+func nearest(palette Palette, r, g, b float64) int {
+	best, bestDistance := 0, math.Inf(1)
+	for i, color := range palette {
+		dr, dg, db := r-float64(color.R), g-float64(color.G), b-float64(color.B)
+		if distance := dr*dr + dg*dg + db*db; distance < bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+	return best
+}
+
+// colorCount associe une couleur au nombre de pixels qui la portent.
+type colorCount struct {
+	pixel ppm.Pixel16
+	count int
+}
+
+// histogram renvoie les couleurs distinctes de src avec leur nombre d'occurrences,
+// triées afin que les quantificateurs soient déterministes.
+func histogram(src *ppm.PPM) []colorCount {
+	width, height := src.Size()
+	counts := make(map[ppm.Pixel16]int)
+	pix, stride := src.Pix(), src.Stride()
+	for y := 0; y < height; y++ {
+		for _, pixel := range pix[y*stride : y*stride+width] {
+			counts[pixel]++
+		}
+	}
+	colors := make([]colorCount, 0, len(counts))
+	for pixel, count := range counts {
+		colors = append(colors, colorCount{pixel, count})
+	}
+	slices.SortFunc(colors, func(a, b colorCount) int {
+		if a.pixel.R != b.pixel.R {
+			return cmp.Compare(a.pixel.R, b.pixel.R)
+		}
+		if a.pixel.G != b.pixel.G {
+			return cmp.Compare(a.pixel.G, b.pixel.G)
+		}
+		return cmp.Compare(a.pixel.B, b.pixel.B)
+	})
+	return colors
+}
+
+// average renvoie la couleur moyenne, pondérée par les occurrences, d'un ensemble de couleurs.
+func average(colors []colorCount) ppm.Pixel16 {
+	var r, g, b, n float64
+	for _, c := range colors {
+		weight := float64(c.count)
+		r += weight * float64(c.pixel.R)
+		g += weight * float64(c.pixel.G)
+		b += weight * float64(c.pixel.B)
+		n += weight
+	}
+	return ppm.Pixel16{R: uint16(math.Round(r / n)), G: uint16(math.Round(g / n)), B: uint16(math.Round(b / n))}
+}
@@ -0,0 +1,214 @@
+package quantize
+
+import (
+	"Netpbm/dither"
+	"Netpbm/ppm"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// quantizers associe à chaque quantificateur son nom.
+var quantizers = map[string]Quantizer{
+	"MedianCut": MedianCut{},
+	"Octree":    Octree{},
+	"KMeans":    KMeans{},
+}
+
+// randomPPM renvoie une image aléatoire de valeur maximale 255.
+func randomPPM(rng *rand.Rand, width, height int) *ppm.PPM {
+	src := ppm.NewPPM(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			src.Set16(x, y, ppm.Pixel16{R: uint16(rng.Intn(256)), G: uint16(rng.Intn(256)), B: uint16(rng.Intn(256))})
+		}
+	}
+	return src
+}
+
+// fromPixels renvoie une image d'une seule ligne formée de pixels.
+func fromPixels(pixels ...ppm.Pixel16) *ppm.PPM {
+	src := ppm.NewPPM(len(pixels), 1)
+	for x, pixel := range pixels {
+		src.Set16(x, 0, pixel)
+	}
+	return src
+}
+
+// gray renvoie une image de 32 × 32 pixels gris de niveau value.
+func gray(value uint16) *ppm.PPM {
+	src := ppm.NewPPM(32, 32)
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			src.Set16(x, y, ppm.Pixel16{R: value, G: value, B: value})
+		}
+	}
+	return src
+}
+
+func TestPaletteSize(t *testing.T) {
+	src := randomPPM(rand.New(rand.NewSource(1)), 32, 32)
+	for name, quantizer := range quantizers {
+		for _, colors := range []int{1, 2, 3, 7, 16, 64, 256} {
+			palette := quantizer.Palette(src, colors)
+			if len(palette) < 1 || len(palette) > colors {
+				t.Errorf("%s : %d couleurs pour %d demandées", name, len(palette), colors)
+			}
+			for _, color := range palette {
+				if color.R > 255 || color.G > 255 || color.B > 255 {
+					t.Errorf("%s : couleur %v au-delà de la valeur maximale", name, color)
+				}
+			}
+		}
+	}
+}
+
+func TestFewColorsExact(t *testing.T) {
+	// Cinq couleurs distinctes, dont deux ne diffèrent que d'une unité
+	pixels := []ppm.Pixel16{{R: 0, G: 0, B: 0}, {R: 255, G: 0, B: 0}, {R: 255, G: 0, B: 0}, {R: 10, G: 200, B: 30}, {R: 11, G: 200, B: 30}, {R: 255, G: 255, B: 255}, {R: 0, G: 0, B: 0}}
+	src := fromPixels(pixels...)
+	for name, quantizer := range quantizers {
+		for _, colors := range []int{5, 8, 256} {
+			dst, err := Quantize(src, quantizer, colors, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := dst.PPM().Pix(); !slices.Equal(got, src.Pix()) {
+				t.Errorf("%s, %d couleurs : %v, %v attendu", name, colors, got, src.Pix())
+			}
+		}
+	}
+}
+
+func TestEmptyImage(t *testing.T) {
+	for name, quantizer := range quantizers {
+		for _, size := range [][2]int{{0, 0}, {0, 3}, {3, 0}} {
+			dst, err := Quantize(ppm.NewPPM(size[0], size[1]), quantizer, 4, Options{Dither: &dither.FloydSteinberg})
+			if err != nil {
+				t.Fatalf("%s, %d × %d : %v", name, size[0], size[1], err)
+			}
+			if width, height := dst.Size(); width != size[0] || height != size[1] || len(dst.Palette()) != 0 || len(dst.Pix()) != 0 {
+				t.Errorf("%s : %d × %d, palette %v, %d pixels", name, width, height, dst.Palette(), len(dst.Pix()))
+			}
+		}
+	}
+}
+
+func TestRemap(t *testing.T) {
+	palette := Palette{{R: 0, G: 0, B: 0}, {R: 255, G: 255, B: 255}, {R: 255, G: 0, B: 0}}
+	src := fromPixels(ppm.Pixel16{R: 10, G: 10, B: 10}, ppm.Pixel16{R: 200, G: 30, B: 20}, ppm.Pixel16{R: 250, G: 240, B: 255}, ppm.Pixel16{R: 255, G: 0, B: 0}, ppm.Pixel16{R: 100, G: 100, B: 100})
+	dst, err := Remap(src, palette, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0, 2, 1, 2, 0}; !slices.Equal(dst.Pix(), want) {
+		t.Errorf("indices %v, %v attendus", dst.Pix(), want)
+	}
+
+	src.SetMaxValue(200)
+	if _, err := Remap(src, palette, Options{}); err == nil {
+		t.Error("Remap accepte une palette au-delà de la valeur maximale")
+	}
+	if _, err := Remap(src, nil, Options{}); err == nil {
+		t.Error("Remap accepte une palette vide")
+	}
+}
+
+func TestMedianCutWeighted(t *testing.T) {
+	// La coupe se fait à la médiane des pixels, et non des couleurs distinctes :
+	// les dix pixels de la troisième couleur forment à eux seuls une boîte
+	src := fromPixels(ppm.Pixel16{R: 0}, ppm.Pixel16{R: 100})
+	heavy := ppm.NewPPM(10, 1)
+	for x := 0; x < 10; x++ {
+		heavy.Set16(x, 0, ppm.Pixel16{R: 200})
+	}
+	hist := append(histogram(src), histogram(heavy)...)
+	if got, want := medianCut(hist, 2), (Palette{{R: 50}, {R: 200}}); !slices.Equal(got, want) {
+		t.Errorf("coupe médiane %v, %v attendu", got, want)
+	}
+}
+
+func TestOctreeMerge(t *testing.T) {
+	// Trois couleurs qui ne diffèrent que par le dernier bit partagent un même parent ;
+	// le blanc, seul dans son sous-arbre, est fusionné en premier sans réduire le compte
+	src := fromPixels(ppm.Pixel16{R: 0, G: 0, B: 0}, ppm.Pixel16{R: 1, G: 0, B: 0}, ppm.Pixel16{R: 0, G: 1, B: 0}, ppm.Pixel16{R: 255, G: 255, B: 255})
+	tests := []struct {
+		colors int
+		want   Palette
+	}{
+		{4, Palette{{R: 0, G: 0, B: 0}, {R: 0, G: 1, B: 0}, {R: 1, G: 0, B: 0}, {R: 255, G: 255, B: 255}}},
+		{3, Palette{{R: 0, G: 0, B: 0}, {R: 255, G: 255, B: 255}}},
+		{2, Palette{{R: 0, G: 0, B: 0}, {R: 255, G: 255, B: 255}}},
+		{1, Palette{{R: 64, G: 64, B: 64}}},
+	}
+	for _, test := range tests {
+		if got := (Octree{}).Palette(src, test.colors); !slices.Equal(got, test.want) {
+			t.Errorf("%d couleurs : %v, %v attendu", test.colors, got, test.want)
+		}
+	}
+}
+
+func TestKMeansConverges(t *testing.T) {
+	src := randomPPM(rand.New(rand.NewSource(2)), 16, 16)
+	palette := KMeans{Iterations: 1000}.Palette(src, 4)
+	// À convergence, chaque centre est la moyenne des couleurs qui lui sont rattachées
+	var sums [4][4]float64
+	for _, c := range histogram(src) {
+		i := nearest(palette, float64(c.pixel.R), float64(c.pixel.G), float64(c.pixel.B))
+		sums[i][0] += float64(c.count) * float64(c.pixel.R)
+		sums[i][1] += float64(c.count) * float64(c.pixel.G)
+		sums[i][2] += float64(c.count) * float64(c.pixel.B)
+		sums[i][3] += float64(c.count)
+	}
+	for i, center := range palette {
+		n := sums[i][3]
+		want := ppm.Pixel16{R: uint16(math.Round(sums[i][0] / n)), G: uint16(math.Round(sums[i][1] / n)), B: uint16(math.Round(sums[i][2] / n))}
+		if center != want {
+			t.Errorf("centre %d : %v, moyenne de ses couleurs %v", i, center, want)
+		}
+	}
+}
+
+func TestRemapDiffuse(t *testing.T) {
+	src := gray(128)
+	palette := Palette{{R: 0, G: 0, B: 0}, {R: 255, G: 255, B: 255}}
+	raster, err := Remap(src, palette, Options{Dither: &dither.FloydSteinberg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	white := 0
+	for _, index := range raster.Pix() {
+		white += index
+	}
+	if ratio := float64(white) / 1024; ratio < 0.45 || ratio > 0.55 {
+		t.Errorf("%.1f %% de blanc, environ 50 %% attendu", 100*ratio)
+	}
+	// Le gris moyen donne un damier symétrique : un gris plus sombre distingue les parcours
+	dark := gray(77)
+	raster, err = Remap(dark, palette, Options{Dither: &dither.FloydSteinberg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	serpentine, err := Remap(dark, palette, Options{Dither: &dither.FloydSteinberg, Serpentine: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Equal(raster.Pix(), serpentine.Pix()) {
+		t.Error("le parcours serpentin donne le même résultat que le parcours ligne par ligne")
+	}
+
+	// Une couleur présente dans la palette ne produit aucune erreur à diffuser
+	exact, err := Remap(src, Palette{{R: 0, G: 0, B: 0}, {R: 128, G: 128, B: 128}, {R: 255, G: 255, B: 255}}, Options{Dither: &dither.Atkinson})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, index := range exact.Pix() {
+		if index != 1 {
+			t.Fatalf("pixel %d : indice %d, 1 attendu", i, index)
+		}
+	}
+	if _, err := Remap(src, palette, Options{Dither: &dither.Kernel{}}); err == nil {
+		t.Error("Remap accepte un noyau de diviseur nul")
+	}
+}